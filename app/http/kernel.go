@@ -1,16 +1,24 @@
 package http
 
-import "goweb/framework/gin"
+import (
+	"goweb/framework"
+	"goweb/framework/gin"
+	"goweb/framework/middleware"
+)
 
 // NewHttpEngine 创建了一个绑定了路由的Web引擎
-func NewHttpEngine() (*gin.Engine, error) {
+func NewHttpEngine(container framework.Container) (*gin.Engine, error) {
 	// 设置为Release，为的是默认在启动中不输出调试信息
 	gin.SetMode(gin.ReleaseMode)
 	// 默认启动一个Web引擎
 	r := gin.New()
+	// 设置服务容器, 路由注册的时候就可以使用容器中的服务
+	r.SetContainer(container)
 	r.Use(gin.Recovery())
+	// 每个请求使用独立的服务作用域
+	r.Use(middleware.Scope())
 	// 业务绑定路由操作
 	Routes(r)
 	// 返回绑定路由后的Web引擎
-	return r,nil
-}
\ No newline at end of file
+	return r, nil
+}
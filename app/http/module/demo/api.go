@@ -2,7 +2,7 @@ package demo
 
 import (
 	demoService "goweb/app/provider/demo"
-	"goweb/framework/contract"
+	"goweb/framework"
 	"goweb/framework/gin"
 )
 
@@ -30,17 +30,12 @@ func NewDemoApi() *DemoApi {
 // @Description 获取所有用户
 // @Produce  json
 // @Tags demo
-// @Success 200 array []UserDTO
+// @Success 200 {array} demo.UserDTO
 // @Router /demo/demo [get]
 func (api *DemoApi) Demo(c *gin.Context) {
-	// appService := c.MustMake(contract.AppKey).(contract.App)
-	// baseFolder := appService.BaseFolder()
-	//users := api.service.GetUsers()
-	//usersDTO := UserModelsToUserDTOs(users)
-	//c.JSON(200, usersDTO)
-	configService := c.MustMake(contract.ConfigKey).(contract.Config)
-	password := configService.GetString("database.mysql.password")
-	c.JSON(200, password)
+	users := api.service.GetUsers()
+	usersDTO := UserModelsToUserDTOs(users)
+	c.JSON(200, usersDTO)
 }
 
 // Demo godoc
@@ -48,10 +43,10 @@ func (api *DemoApi) Demo(c *gin.Context) {
 // @Description 获取所有学生
 // @Produce  json
 // @Tags demo
-// @Success 200 array []UserDTO
+// @Success 200 {array} demo.UserDTO
 // @Router /demo/demo2 [get]
 func (api *DemoApi) Demo2(c *gin.Context) {
-	demoProvider := c.MustMake(demoService.DemoKey).(demoService.IService)
+	demoProvider := framework.MustMakeKey[demoService.IService](c, demoService.DemoKey)
 	students := demoProvider.GetAllStudent()
 	usersDTO := StudentsToUserDTOs(students)
 	c.JSON(200, usersDTO)
@@ -2,16 +2,27 @@ package http
 
 import (
 	"goweb/app/http/module/demo"
+	"goweb/framework"
+	"goweb/framework/contract"
 
 	"goweb/framework/gin"
 	"goweb/framework/middleware/static"
+	"goweb/framework/middleware/swagger"
+
+	swaggerFiles "github.com/swaggo/files"
 )
 
 func Routes(r *gin.Engine) {
+	configService := framework.MustMake[contract.Config](r.Container)
 
 	// /路径先去./dist目录下查找文件是否存在，找到使用文件服务提供服务
 	r.Use(static.Serve("/", static.LocalFile("./dist", false)))
 	// r.Static("/dist/", "./dist/")
 
+	// 如果配置了swagger，则显示swagger的中间件
+	if configService.GetBool("app.swagger_open") {
+		r.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler))
+	}
+
 	demo.Register(r)
-}
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+// Package http API.
+// @title goweb
+// @version 1.0
+// @description goweb框架的接口文档
+
+// @contact.name cheng
+// @contact.email wolfsix111@163.com
+
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @BasePath /
+// @query.collection.format multi
+package http
+
+import (
+	// 引入swagger gen生成的文档, 注册到swag中
+	_ "goweb/app/http/swagger"
+)
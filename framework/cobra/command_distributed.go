@@ -1,6 +1,7 @@
 package cobra
 
 import (
+	"goweb/framework"
 	"goweb/framework/contract"
 	"log"
 	"time"
@@ -29,8 +30,8 @@ func (c *Command)  AddDistributedCronCommand(serviceName string, spec string, cm
 		ServiceName: serviceName,
 	})
 
-	appService := root.GetContainer().MustMake(contract.AppKey).(contract.App)
-	distributeServce := root.GetContainer().MustMake(contract.DistributedKey).(contract.Distributed)
+	appService := framework.MustMake[contract.App](root.GetContainer())
+	distributeServce := framework.MustMake[contract.Distributed](root.GetContainer())
 	appID := appService.AppID()
 
 	// 复制要执行的command为cronCmd，并且设置为rootCmd
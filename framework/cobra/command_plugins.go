@@ -16,6 +16,21 @@ func (c *Command) GetContainer() framework.Container{
 	return c.Root().container
 }
 
+// Make 从容器中获取服务, 这样Command可以直接作为 framework.Make[T] 的参数
+func (c *Command) Make(key string) (interface{}, error) {
+	return c.GetContainer().Make(key)
+}
+
+// MustMake 从容器中获取服务, 获取失败的时候panic
+func (c *Command) MustMake(key string) interface{} {
+	return c.GetContainer().MustMake(key)
+}
+
+// MakeNew 根据参数从容器中实例化一个新的服务
+func (c *Command) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return c.GetContainer().MakeNew(key, params)
+}
+
 
 // CronSpec 保存Cron命令的信息，用于展示
 type CronSpec struct {
@@ -2,25 +2,51 @@ package command
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
-	"log"
+	"goweb/framework/util"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+
+	"github.com/erikdubbelboer/gspt"
+	"github.com/sevlyar/go-daemon"
 )
+
+// appAddress 服务启动地址
 var appAddress = ""
 
+// appDaemon 是否以守护进程方式启动
+var appDaemon = false
+
+// defaultAppAddress 没有任何配置的时候使用的启动地址
+const defaultAppAddress = ":8888"
+
+// defaultAppCloseWait 关闭服务的时候默认等待的秒数
+const defaultAppCloseWait = 5
+
 // initAppCommand 初始化app命令和其子命令
 func initAppCommand() *cobra.Command {
-	appStartCommand.Flags().StringVar(&appAddress, "address", ":8888", "设置app启动的地址，默认为:8888")
+	appStartCommand.Flags().StringVar(&appAddress, "address", "", "设置app启动的地址，默认为:8888")
+	appStartCommand.Flags().BoolVarP(&appDaemon, "daemon", "d", false, "以守护进程方式启动")
+	appRestartCommand.Flags().StringVar(&appAddress, "address", "", "设置app启动的地址, 只在没有正在运行的服务时生效")
+
 	appCommand.AddCommand(appStartCommand)
+	appCommand.AddCommand(appRestartCommand)
+	appCommand.AddCommand(appStopCommand)
+	appCommand.AddCommand(appStateCommand)
 	return appCommand
 }
 
-
 // AppCommand 是命令行参数第一级为app的命令，它没有实际功能，只是打印帮助文档
 var appCommand = &cobra.Command{
 	Use:   "app",
@@ -32,6 +58,142 @@ var appCommand = &cobra.Command{
 		return nil
 	},
 }
+
+// getAppAddress 获取服务启动地址, 优先级为: 命令行参数 > 配置文件app.address > 环境变量ADDRESS > 默认值:8888
+func getAppAddress(container framework.Container) string {
+	if appAddress != "" {
+		return appAddress
+	}
+	configService := framework.MustMake[contract.Config](container)
+	if configService.IsExist("app.address") && configService.GetString("app.address") != "" {
+		return configService.GetString("app.address")
+	}
+	envService := framework.MustMake[contract.Env](container)
+	if envService.Get("ADDRESS") != "" {
+		return envService.Get("ADDRESS")
+	}
+	return defaultAppAddress
+}
+
+// getAppCloseWait 获取关闭服务的时候等待的时间, 读取配置文件app.close_wait, 单位秒
+func getAppCloseWait(container framework.Container) time.Duration {
+	configService := framework.MustMake[contract.Config](container)
+	closeWait := defaultAppCloseWait
+	if configService.IsExist("app.close_wait") {
+		closeWait = configService.GetInt("app.close_wait")
+	}
+	return time.Duration(closeWait) * time.Second
+}
+
+// getAppPid 读取pid文件中的进程id, pid文件不存在或者为空的时候返回0
+func getAppPid(container framework.Container) (int, error) {
+	appService := framework.MustMake[contract.App](container)
+	serverPidFile := filepath.Join(appService.RuntimeFolder(), "app.pid")
+
+	content, err := ioutil.ReadFile(serverPidFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(content) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+// startAppServe 启动服务, 收到SIGUSR2信号的时候平滑重启, 收到退出信号后等待close_wait时间优雅关闭
+func startAppServe(server *http.Server, container framework.Container) error {
+	appService := framework.MustMake[contract.App](container)
+	serverPidFile := filepath.Join(appService.RuntimeFolder(), "app.pid")
+
+	ln, inherited, err := getAppListener(server.Addr)
+	if err != nil {
+		return err
+	}
+
+	// 在写入pid文件和通知父进程之前监控信号, 否则这期间收到的SIGTERM会直接结束进程, 不会优雅关闭也不会删除pid文件
+	// 监控信号：SIGINT, SIGTERM, SIGQUIT 退出, SIGUSR2 平滑重启
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR2)
+	defer signal.Stop(quit)
+
+	// 记录当前的连接, 关闭的时候等待已经接收的连接处理完成
+	tracker := newAppConnTracker()
+	server.ConnState = tracker.hook(server.ConnState)
+
+	serverErr := make(chan error, 1)
+	// 这个goroutine是启动服务的goroutine
+	go func() {
+		serverErr <- server.Serve(ln)
+	}()
+
+	pid := os.Getpid()
+	if err := writeAppPidFile(serverPidFile, pid); err != nil {
+		return err
+	}
+	defer removeAppPidFile(serverPidFile, pid)
+	fmt.Println("[PID]", pid)
+
+	// 平滑重启启动的子进程, 已经开始提供服务了, 通知父进程结束
+	if inherited {
+		fmt.Println("app serve inherited listener from parent, pid:", os.Getppid())
+		if err := syscall.Kill(os.Getppid(), syscall.SIGTERM); err != nil {
+			fmt.Println("notify parent error:", err)
+		}
+	}
+
+	// 当前的goroutine等待信号量
+	for {
+		// 这里会阻塞当前goroutine等待信号, 或者服务启动失败
+		select {
+		case err := <-serverErr:
+			if e := shutdownContainer(container); e != nil {
+				fmt.Println("shutdown container error:", e)
+			}
+			return err
+		case sig := <-quit:
+			if sig == syscall.SIGUSR2 {
+				// 启动子进程, 等待子进程开始提供服务后发送SIGTERM通知当前进程结束
+				childPid, err := forkAppChild(ln, server.Addr, appService.BaseFolder())
+				if err != nil {
+					fmt.Println("graceful restart error:", err)
+					continue
+				}
+				fmt.Println("graceful restart, child pid:", childPid)
+				continue
+			}
+		}
+		break
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), getAppCloseWait(container))
+	defer cancel()
+
+	// 先停止接收新的连接, 并关闭keep-alive, 等待已经接收的连接处理完成
+	// Server.Shutdown会直接丢弃关闭之后才读到的请求, 所以不能一开始就调用Shutdown
+	ln.Close()
+	server.SetKeepAlivesEnabled(false)
+	tracker.wait(timeoutCtx)
+
+	// 调用Server.Shutdown graceful结束, 关闭剩余的连接
+	shutdownErr := server.Shutdown(timeoutCtx)
+
+	// 请求都处理完成之后关闭容器中的服务, 比如将日志写入磁盘, 停止配置文件的监控
+	if err := shutdownContainer(container); err != nil {
+		fmt.Println("shutdown container error:", err)
+	}
+	return shutdownErr
+}
+
+// shutdownContainer 在close_wait时间内关闭容器中已经实例化的服务
+func shutdownContainer(container framework.Container) error {
+	ctx, cancel := context.WithTimeout(context.Background(), getAppCloseWait(container))
+	defer cancel()
+	return container.Shutdown(ctx)
+}
+
 // appStartCommand 启动一个Web服务
 var appStartCommand = &cobra.Command{
 	Use:   "start",
@@ -39,39 +201,159 @@ var appStartCommand = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		// 从Command中获取服务容器
 		container := c.GetContainer()
+		// 配置不符合注册的结构的时候拒绝启动, 具体的错误在加载配置的时候已经打印
+		if violations := framework.MustMake[contract.Config](container).Validate(); len(violations) > 0 {
+			return fmt.Errorf("config has %d violations, run `goweb config validate` for details", len(violations))
+		}
 		// 从服务容器中获取kernel的服务实例
-		kernelService := container.MustMake(contract.KernelKey).(contract.Kernel)
+		kernelService := framework.MustMake[contract.Kernel](container)
 		// 从kernel服务实例中获取引擎
 		core := kernelService.HttpEngine()
 
+		address := getAppAddress(container)
 		// 创建一个Server服务
 		server := &http.Server{
 			Handler: core,
-			Addr:    ":8888",
+			Addr:    address,
+		}
+
+		appService := framework.MustMake[contract.App](container)
+		// 设置app的日志地址, 进程id文件由startAppServe维护
+		serverLogFile := filepath.Join(appService.LogFolder(), "app.log")
+		currentFolder := appService.BaseFolder()
+		for _, folder := range []string{appService.RuntimeFolder(), appService.LogFolder()} {
+			if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+				return err
+			}
+		}
+
+		// daemon 模式
+		if appDaemon {
+			// 创建一个Context
+			cntxt := &daemon.Context{
+				// 设置日志文件
+				LogFileName: serverLogFile,
+				LogFilePerm: 0640,
+				// 设置工作路径
+				WorkDir: currentFolder,
+				// 设置所有文件的mask，默认为750
+				Umask: 027,
+				// 子进程的参数，按照这个参数设置，子进程的命令为 ./goweb app start --daemon=true --address=:8888
+				// 保留启动时的命令路径, 平滑重启的时候使用这个路径启动新的子进程
+				Args: []string{os.Args[0], "app", "start", "--daemon=true", "--address=" + address},
+			}
+			// 启动子进程，d不为空表示当前是父进程，d为空表示当前是子进程
+			d, err := cntxt.Reborn()
+			if err != nil {
+				return err
+			}
+			if d != nil {
+				// 父进程直接打印启动成功信息，不做任何操作
+				fmt.Println("app serve started, pid:", d.Pid)
+				fmt.Println("address:", address)
+				fmt.Println("log file:", serverLogFile)
+				return nil
+			}
+			defer cntxt.Release()
+			// 子进程执行真正的app启动操作
+			fmt.Println("daemon started")
+			gspt.SetProcTitle("goweb app")
+			if err := startAppServe(server, container); err != nil {
+				fmt.Println(err)
+			}
+			return nil
 		}
 
-		// 这个goroutine是启动服务的goroutine
-		go func() {
-			server.ListenAndServe()
-		}()
+		// 非daemon模式，直接执行
+		gspt.SetProcTitle("goweb app")
 
-		// 当前的goroutine等待信号量
-		quit := make(chan os.Signal)
-		// 监控信号：SIGINT, SIGTERM, SIGQUIT
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-		// 这里会阻塞当前goroutine等待信号
-		<-quit
+		fmt.Println("app serve url:", address)
+		if err := startAppServe(server, container); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
 
-		// 调用Server.Shutdown graceful结束
-		timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+// appRestartCommand 重启一个Web服务
+var appRestartCommand = &cobra.Command{
+	Use:   "restart",
+	Short: "重新启动一个Web服务",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
 
-		if err := server.Shutdown(timeoutCtx); err != nil {
-			log.Fatal("Server Shutdown:", err)
+		pid, err := getAppPid(container)
+		if err != nil {
+			return err
+		}
+		if pid != 0 && util.CheckProcessExist(pid) {
+			// 发送SIGUSR2信号, 进程会将监听的socket交给新启动的子进程, 然后等待请求处理完成后退出
+			if err := syscall.Kill(pid, syscall.SIGUSR2); err != nil {
+				return err
+			}
+			// 等待子进程写入新的pid文件, 并且旧的进程已经退出, 最多等待close_wait时间再加5秒
+			timeout := getAppCloseWait(container) + 5*time.Second
+			for start := time.Now(); time.Since(start) < timeout; time.Sleep(100 * time.Millisecond) {
+				newPid, err := getAppPid(container)
+				if err != nil || newPid == 0 || newPid == pid || !util.CheckProcessExist(newPid) {
+					continue
+				}
+				if util.CheckProcessExist(pid) {
+					continue
+				}
+				fmt.Println("app服务重启成功, 旧进程:", pid, "新进程:", newPid)
+				return nil
+			}
+			return errors.New("平滑重启进程失败:" + strconv.Itoa(pid) + ", 请查看日志")
 		}
 
+		// 没有正在运行的服务, 直接daemon方式启动app
+		appDaemon = true
+		return appStartCommand.RunE(c, args)
+	},
+}
+
+// appStopCommand 停止一个已经启动的app服务
+var appStopCommand = &cobra.Command{
+	Use:   "stop",
+	Short: "停止一个已经启动的app服务",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+
+		pid, err := getAppPid(container)
+		if err != nil {
+			return err
+		}
+		if pid == 0 || !util.CheckProcessExist(pid) {
+			fmt.Println("没有app服务存在")
+			return nil
+		}
+
+		// 发送SIGTERM命令, 进程退出的时候会删除自己的pid文件
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			return err
+		}
+		fmt.Println("停止进程:", pid)
 		return nil
 	},
 }
 
+// appStateCommand 获取启动的app的pid
+var appStateCommand = &cobra.Command{
+	Use:   "state",
+	Short: "获取启动的app的pid",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
 
+		pid, err := getAppPid(container)
+		if err != nil {
+			return err
+		}
+		if pid != 0 && util.CheckProcessExist(pid) {
+			fmt.Println("app服务已经启动, pid:", pid)
+			return nil
+		}
+		fmt.Println("没有app服务存在")
+		return nil
+	},
+}
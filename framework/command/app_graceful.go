@@ -0,0 +1,166 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sevlyar/go-daemon"
+)
+
+// appListenerFdEnv 平滑重启的时候, 父进程通过这个环境变量告诉子进程监听的socket是继承下来的哪个文件描述符
+const appListenerFdEnv = "GOWEB_LISTENER_FD"
+
+// getAppListener 获取服务监听的socket, 如果是平滑重启启动的子进程, 使用从父进程继承的文件描述符, 否则监听address
+// 第二个返回值表示socket是否是从父进程继承的
+func getAppListener(address string) (net.Listener, bool, error) {
+	fd := os.Getenv(appListenerFdEnv)
+	if fd == "" {
+		ln, err := net.Listen("tcp", address)
+		return ln, false, err
+	}
+	// 防止再次启动子进程的时候被误用
+	os.Unsetenv(appListenerFdEnv)
+
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return nil, false, errors.New("invalid " + appListenerFdEnv + ": " + fd)
+	}
+	file := os.NewFile(uintptr(n), "listener")
+	defer file.Close()
+	// FileListener会复制一份文件描述符, 所以原来的file可以关闭
+	ln, err := net.FileListener(file)
+	return ln, true, err
+}
+
+// forkAppChild 启动一个新的子进程, 并将监听的socket通过ExtraFiles传递给子进程, 返回子进程的pid
+// 子进程使用 app start --address=xxx 在前台启动, 日志输出沿用当前进程的输出
+func forkAppChild(ln net.Listener, address string, baseFolder string) (int, error) {
+	tcpListener, ok := ln.(*net.TCPListener)
+	if !ok {
+		return 0, errors.New("listener is not tcp listener")
+	}
+	file, err := tcpListener.File()
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// 使用启动时候的命令路径, 这样重新部署替换了可执行文件之后, 子进程使用的是新的可执行文件
+	execPath := os.Args[0]
+	if !strings.Contains(execPath, string(os.PathSeparator)) {
+		if execPath, err = exec.LookPath(execPath); err != nil {
+			return 0, err
+		}
+	} else if !filepath.IsAbs(execPath) {
+		execPath = filepath.Join(baseFolder, execPath)
+	}
+
+	// 去掉daemon的标记, 子进程不需要再进行daemon操作
+	env := make([]string, 0, len(os.Environ())+1)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, daemon.MARK_NAME+"=") || strings.HasPrefix(kv, appListenerFdEnv+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	// ExtraFiles中的第一个文件在子进程中的文件描述符为3
+	env = append(env, appListenerFdEnv+"=3")
+
+	cmd := &exec.Cmd{
+		Path:       execPath,
+		Args:       []string{os.Args[0], "app", "start", "--address=" + address},
+		Env:        env,
+		Dir:        baseFolder,
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		ExtraFiles: []*os.File{file},
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	// 回收子进程, 防止子进程启动失败之后成为僵尸进程
+	go cmd.Wait()
+	return cmd.Process.Pid, nil
+}
+
+// writeAppPidFile 写入pid文件, 先写临时文件再重命名, 保证读取pid文件的时候不会读到写了一半的内容
+func writeAppPidFile(pidFile string, pid int) error {
+	tmp := pidFile + "." + strconv.Itoa(pid) + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, pidFile)
+}
+
+// removeAppPidFile 删除pid文件, 只有当pid文件中记录的还是当前进程的时候才删除, 防止删除了平滑重启后子进程的pid文件
+func removeAppPidFile(pidFile string, pid int) error {
+	content, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if strings.TrimSpace(string(content)) != strconv.Itoa(pid) {
+		return nil
+	}
+	return os.Remove(pidFile)
+}
+
+// appConnTracker 记录服务当前的连接
+type appConnTracker struct {
+	lock  sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// newAppConnTracker 初始化appConnTracker
+func newAppConnTracker() *appConnTracker {
+	return &appConnTracker{conns: map[net.Conn]struct{}{}}
+}
+
+// hook 返回用于http.Server.ConnState的回调, 如果原来已经设置了回调, 也会调用原来的回调
+func (t *appConnTracker) hook(prev func(net.Conn, http.ConnState)) func(net.Conn, http.ConnState) {
+	return func(conn net.Conn, state http.ConnState) {
+		t.lock.Lock()
+		switch state {
+		case http.StateNew:
+			t.conns[conn] = struct{}{}
+		case http.StateClosed, http.StateHijacked:
+			delete(t.conns, conn)
+		}
+		t.lock.Unlock()
+		if prev != nil {
+			prev(conn, state)
+		}
+	}
+}
+
+// count 当前的连接数
+func (t *appConnTracker) count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.conns)
+}
+
+// wait 等待所有连接关闭, 或者ctx结束
+func (t *appConnTracker) wait(ctx context.Context) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for t.count() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_PidFile(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+
+	require.NoError(t, writeAppPidFile(pidFile, 100))
+	content, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+	assert.Equal(t, "100", string(content))
+	// 没有遗留临时文件
+	matches, _ := filepath.Glob(pidFile + ".*")
+	assert.Empty(t, matches)
+
+	// 平滑重启后子进程覆盖了pid文件, 父进程不能删除
+	require.NoError(t, writeAppPidFile(pidFile, 200))
+	require.NoError(t, removeAppPidFile(pidFile, 100))
+	assert.FileExists(t, pidFile)
+
+	require.NoError(t, removeAppPidFile(pidFile, 200))
+	assert.NoFileExists(t, pidFile)
+	// pid文件不存在的时候不报错
+	assert.NoError(t, removeAppPidFile(pidFile, 200))
+}
+
+func TestApp_GetListener(t *testing.T) {
+	// 没有继承的socket时监听address
+	ln, inherited, err := getAppListener("127.0.0.1:0")
+	require.NoError(t, err)
+	assert.False(t, inherited)
+
+	// 从环境变量中的文件描述符恢复socket
+	file, err := ln.(*net.TCPListener).File()
+	require.NoError(t, err)
+	t.Setenv(appListenerFdEnv, strconv.Itoa(int(file.Fd())))
+	child, inherited, err := getAppListener("")
+	require.NoError(t, err)
+	assert.True(t, inherited)
+	assert.Equal(t, ln.Addr().String(), child.Addr().String())
+	// 使用之后清除环境变量, 防止再次启动子进程的时候被误用
+	_, exist := os.LookupEnv(appListenerFdEnv)
+	assert.False(t, exist)
+	child.Close()
+	ln.Close()
+
+	t.Setenv(appListenerFdEnv, "abc")
+	_, _, err = getAppListener("")
+	assert.EqualError(t, err, "invalid "+appListenerFdEnv+": abc")
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
 	"goweb/framework/util"
@@ -79,7 +80,7 @@ var cmdCreateCommand = &cobra.Command{
 		}
 
 		// 判断文件不存在
-		app := container.MustMake(contract.AppKey).(contract.App)
+		app := framework.MustMake[contract.App](container)
 
 		pFolder := app.CommandFolder()
 		subFolders, err := util.SubDir(pFolder)
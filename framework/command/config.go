@@ -1,16 +1,39 @@
 package command
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
+	"goweb/framework/provider/config"
+	"goweb/framework/util"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/kr/pretty"
+	"gopkg.in/yaml.v3"
 )
 
 // initConfigCommand 获取配置相关的命令
 func initConfigCommand() *cobra.Command {
+	configGetCommand.Flags().BoolVar(&configExplain, "explain", false, "显示配置项在每一层配置中的值和生效的来源")
+	configGetCommand.Flags().BoolVar(&configReveal, "reveal", false, "显示加密配置项解密之后的值")
 	configCommand.AddCommand(configGetCommand)
+	configCommand.AddCommand(configSetCommand)
+	configCommand.AddCommand(configDiffCommand)
+	configCommand.AddCommand(configEncryptCommand)
+	configCommand.AddCommand(configDecryptCommand)
+	configCommand.AddCommand(configRotateKeyCommand)
+	configValidateCommand.Flags().StringVar(&configValidateEnv, "env", "", "校验某个环境的配置, 默认为当前环境")
+	configCommand.AddCommand(configValidateCommand)
 	return configCommand
 }
 
@@ -26,13 +49,44 @@ var configCommand = &cobra.Command{
 	},
 }
 
+// configExplain 是否显示配置项的来源
+var configExplain = false
+
+// configReveal 是否显示加密配置项解密之后的值
+var configReveal = false
+
+// redactedValue 隐藏之后显示的值
+const redactedValue = "******"
+
+// redactConfig 将配置中由加密值解密得到的配置项替换为redactedValue, map和列表递归处理, 不修改原来的值
+func redactConfig(configService contract.Config, key string, val interface{}) interface{} {
+	if m, ok := val.(map[string]interface{}); ok {
+		ret := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			ret[k] = redactConfig(configService, key+"."+k, v)
+		}
+		return ret
+	}
+	if list, ok := val.([]interface{}); ok {
+		ret := make([]interface{}, len(list))
+		for i, v := range list {
+			ret[i] = redactConfig(configService, key+"."+strconv.Itoa(i), v)
+		}
+		return ret
+	}
+	if configService.IsSecret(key) {
+		return redactedValue
+	}
+	return val
+}
+
 // envListCommand 获取所有的App环境变量
 var configGetCommand = &cobra.Command{
 	Use:   "get",
 	Short: "获取某个配置信息",
 	RunE: func(c *cobra.Command, args []string) error {
 		container := c.GetContainer()
-		configService := container.MustMake(contract.ConfigKey).(contract.Config)
+		configService := framework.MustMake[contract.Config](container)
 		if len(args) != 1 {
 			fmt.Println("参数错误")
 			return nil
@@ -44,7 +98,317 @@ var configGetCommand = &cobra.Command{
 			return nil
 		}
 
+		// 加密的配置项默认隐藏解密之后的值
+		if !configReveal {
+			val = redactConfig(configService, configPath, val)
+		}
 		fmt.Printf("%# v\n", pretty.Formatter(val))
+		if configExplain {
+			sources := configService.Explain(configPath)
+			if !configReveal {
+				for i := range sources {
+					sources[i].Value = redactConfig(configService, configPath, sources[i].Value)
+				}
+			}
+			printConfigSources(c, sources)
+		}
 		return nil
 	},
-}
\ No newline at end of file
+}
+
+// printConfigSources 按照优先级从高到低打印配置项的来源, 生效的来源使用*标记
+func printConfigSources(c *cobra.Command, sources []contract.ConfigSource) {
+	appService := framework.MustMake[contract.App](c)
+	ps := [][]string{{"", "layer", "file", "value"}}
+	for i, source := range sources {
+		mark := ""
+		if i == 0 {
+			mark = "*"
+		}
+		// 配置项包含多个配置文件的时候, 文件之间使用逗号分隔
+		files := []string{}
+		for _, file := range strings.Split(source.File, ", ") {
+			if rel, err := filepath.Rel(appService.BaseFolder(), file); err == nil && file != "" {
+				file = rel
+			}
+			files = append(files, file)
+		}
+		ps = append(ps, []string{mark, source.Layer, strings.Join(files, ", "), fmt.Sprintf("%v", source.Value)})
+	}
+	fmt.Println("来源(优先级从高到低):")
+	util.PrettyPrint(ps)
+	if len(sources) > 1 {
+		if _, ok := sources[0].Value.(map[string]interface{}); ok {
+			fmt.Println("配置项为map, 生效的值为所有配置层合并之后的结果")
+		}
+	}
+}
+// configValidateEnv 需要校验的环境
+var configValidateEnv = ""
+
+// configValidateCommand 按照服务提供者注册的结构校验配置, 有不符合的配置项的时候返回错误, 可以用于CI
+var configValidateCommand = &cobra.Command{
+	Use:   "validate",
+	Short: "校验配置文件",
+	Example: "goweb config validate --env=production",
+	// 校验失败的时候只打印错误, 不打印使用帮助
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		appService := framework.MustMake[contract.App](container)
+		envService := framework.MustMake[contract.Env](container)
+
+		env := configValidateEnv
+		if env == "" {
+			env = envService.AppEnv()
+		}
+		// 使用需要校验的环境重新加载一份配置, 不影响当前的配置服务
+		configService, err := loadEnvConfig(container, env)
+		if err != nil {
+			return err
+		}
+		defer configService.Shutdown(context.Background())
+
+		violations := configService.Validate()
+		if len(violations) == 0 {
+			fmt.Println("环境", env, "的配置校验通过")
+			return nil
+		}
+		ps := [][]string{{"file", "key", "message"}}
+		for _, violation := range violations {
+			file := violation.File
+			if rel, err := filepath.Rel(appService.BaseFolder(), file); err == nil && file != "" {
+				file = rel
+			}
+			ps = append(ps, []string{file, violation.Key, violation.Message})
+		}
+		util.PrettyPrint(ps)
+		return fmt.Errorf("环境 %s 的配置有 %d 个错误", env, len(violations))
+	},
+}
+
+// loadEnvConfig 加载某个环境的配置, 不影响当前的配置服务, 使用完需要调用Shutdown
+func loadEnvConfig(container framework.Container, env string) (*config.Config, error) {
+	appService := framework.MustMake[contract.App](container)
+	envService := framework.MustMake[contract.Env](container)
+	ins, err := config.NewConfig(nil, appService.ConfigFolder(), env, envService.All(), appService.RuntimeFolder())
+	if err != nil {
+		return nil, err
+	}
+	return ins.(*config.Config), nil
+}
+
+// configSetCommand 修改某个配置, 写回到配置所在的yaml文件
+var configSetCommand = &cobra.Command{
+	Use:          "set",
+	Short:        "修改某个配置, 并且写回到配置文件",
+	Example:      "goweb config set log.level debug",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("参数错误, 例如: goweb config set log.level debug")
+		}
+		configService := framework.MustMake[contract.Config](c.GetContainer())
+		// 按照yaml的格式解析值, 比如 10 为数字, true 为布尔值
+		var val interface{} = args[1]
+		if err := yaml.Unmarshal([]byte(args[1]), &val); err != nil {
+			val = args[1]
+		}
+		if err := configService.Set(args[0], val); err != nil {
+			return err
+		}
+		if sources := configService.Explain(args[0]); len(sources) > 0 {
+			fmt.Println("配置", args[0], "已写入", sources[0].File)
+		}
+		return nil
+	},
+}
+
+// configDiffCommand 比较两个环境的配置
+var configDiffCommand = &cobra.Command{
+	Use:          "diff",
+	Short:        "比较两个环境的配置, 显示缺少, 多余和不同的配置项",
+	Example:      "goweb config diff development production",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("参数错误, 例如: goweb config diff development production")
+		}
+		container := c.GetContainer()
+		from, err := loadEnvConfig(container, args[0])
+		if err != nil {
+			return err
+		}
+		defer from.Shutdown(context.Background())
+		to, err := loadEnvConfig(container, args[1])
+		if err != nil {
+			return err
+		}
+		defer to.Shutdown(context.Background())
+
+		keys := map[string]struct{}{}
+		for _, key := range append(from.Keys(), to.Keys()...) {
+			keys[key] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		ps := [][]string{{"key", "diff", args[0], args[1]}}
+		for _, key := range sorted {
+			fromVal, toVal := from.Get(key), to.Get(key)
+			status := ""
+			switch {
+			case fromVal != nil && toVal == nil:
+				status = "missing"
+			case fromVal == nil && toVal != nil:
+				status = "extra"
+			case !reflect.DeepEqual(fromVal, toVal):
+				status = "different"
+			default:
+				continue
+			}
+			// 加密的配置项和名称敏感的配置项不显示值
+			secret := from.IsSecret(key) || to.IsSecret(key) || isSecretName(key[strings.LastIndex(key, ".")+1:])
+			ps = append(ps, []string{key, status, diffValue(fromVal, secret), diffValue(toVal, secret)})
+		}
+		if len(ps) == 1 {
+			fmt.Println("环境", args[0], "和", args[1], "的配置没有差异")
+			return nil
+		}
+		util.PrettyPrint(ps)
+		return nil
+	},
+}
+
+// diffValue 比较配置的时候显示的值
+func diffValue(val interface{}, secret bool) string {
+	if val == nil {
+		return "-"
+	}
+	if secret {
+		return redactedValue
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// configSecretKey 读取加密配置使用的密钥
+func configSecretKey(container framework.Container) ([]byte, error) {
+	appService := framework.MustMake[contract.App](container)
+	envService := framework.MustMake[contract.Env](container)
+	return config.LoadSecretKey(appService.ConfigFolder(), envService.All())
+}
+
+// readSecretArg 读取需要加解密的值, 没有参数的时候从标准输入读取, 避免明文出现在命令历史中
+func readSecretArg(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	bf, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bf), "\r\n"), nil
+}
+
+// configEncryptCommand 加密一个值, 输出的enc(...)可以直接写在配置文件中
+var configEncryptCommand = &cobra.Command{
+	Use:          "encrypt",
+	Short:        "加密一个配置的值, 输出可以直接写在配置文件中",
+	Example:      "goweb config encrypt 123456\necho -n 123456 | goweb config encrypt",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		key, err := configSecretKey(c.GetContainer())
+		if err != nil {
+			return err
+		}
+		plain, err := readSecretArg(args)
+		if err != nil {
+			return err
+		}
+		value, err := config.Encrypt(key, plain)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// configDecryptCommand 解密一个enc(...)的值
+var configDecryptCommand = &cobra.Command{
+	Use:          "decrypt",
+	Short:        "解密一个enc(...)格式的配置值",
+	Example:      "goweb config decrypt 'enc(...)'",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		key, err := configSecretKey(c.GetContainer())
+		if err != nil {
+			return err
+		}
+		value, err := readSecretArg(args)
+		if err != nil {
+			return err
+		}
+		plain, err := config.Decrypt(key, strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		fmt.Println(plain)
+		return nil
+	},
+}
+
+// configRotateKeyCommand 生成新的密钥, 并且使用新的密钥重新加密所有配置文件中的加密值
+var configRotateKeyCommand = &cobra.Command{
+	Use:          "rotate-key",
+	Short:        "生成新的配置密钥, 并且重新加密所有配置文件中的加密值",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		appService := framework.MustMake[contract.App](container)
+		envService := framework.MustMake[contract.Env](container)
+
+		oldKey, err := config.LoadSecretKey(appService.ConfigFolder(), envService.All())
+		if err != nil {
+			return err
+		}
+		encoded, err := config.GenerateSecretKey()
+		if err != nil {
+			return err
+		}
+		newKey, _ := base64.StdEncoding.DecodeString(encoded)
+
+		// 所有的值都重新加密成功之后, 先保存新的密钥再修改配置文件, 有任意一个值解密失败或者密钥保存失败的时候不修改任何文件
+		_, keyFile := config.SecretKeySource(appService.ConfigFolder(), envService.All())
+		files, err := config.RotateSecrets(appService.ConfigFolder(), oldKey, newKey, func() error {
+			if keyFile == "" {
+				// 密钥来自环境变量, 需要手动更新
+				fmt.Println("新的密钥, 请更新环境变量", config.SecretKeyEnv+":")
+				fmt.Println(encoded)
+				return nil
+			}
+			backup, err := config.SaveSecretKey(keyFile, encoded)
+			if err != nil {
+				return err
+			}
+			fmt.Println("新的密钥已经写入:", keyFile)
+			if backup != "" {
+				fmt.Println("原来的密钥备份在:", backup)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if rel, err := filepath.Rel(appService.BaseFolder(), file); err == nil {
+				file = rel
+			}
+			fmt.Println("重新加密:", file)
+		}
+		return nil
+	},
+}
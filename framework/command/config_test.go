@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"goweb/framework"
+	"goweb/framework/provider/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Redact(t *testing.T) {
+	encoded, err := config.GenerateSecretKey()
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	secret, err := config.Encrypt(key, "123")
+	require.NoError(t, err)
+
+	base := t.TempDir()
+	content := "password: " + secret + "\nhosts:\n  - a\n  - " + secret + "\nnodes:\n  - user: root\n    password: " + secret + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(base, "database.yaml"), []byte(content), 0644))
+	ins, err := config.NewConfig(framework.NewContainer(), base, "testing", map[string]string{config.SecretKeyEnv: encoded})
+	require.NoError(t, err)
+	conf := ins.(*config.Config)
+	defer conf.Shutdown(context.Background())
+
+	val := conf.Get("database")
+	assert.Equal(t, map[string]interface{}{
+		"password": redactedValue,
+		"hosts":    []interface{}{"a", redactedValue},
+		"nodes":    []interface{}{map[string]interface{}{"user": "root", "password": redactedValue}},
+	}, redactConfig(conf, "database", val))
+	// 不修改原来的值
+	assert.Equal(t, "123", conf.GetString("database.hosts.1"))
+}
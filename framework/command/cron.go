@@ -1,12 +1,16 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
 	"goweb/framework/util"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"syscall"
@@ -58,6 +62,29 @@ var cronListCommand = &cobra.Command{
 	},
 }
 
+// runCron 启动cron任务, 收到退出信号之后等待正在执行的任务结束, 再关闭容器中的服务
+func runCron(cmd *cobra.Command) error {
+	container := cmd.GetContainer()
+	if cmd.Root().Cron == nil {
+		return errors.New("no cron job registered")
+	}
+	cmd.Root().Cron.Start()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	<-quit
+
+	// 等待正在执行的任务结束, 最多等待close_wait时间
+	ctx, cancel := context.WithTimeout(context.Background(), getAppCloseWait(container))
+	defer cancel()
+	select {
+	case <-cmd.Root().Cron.Stop().Done():
+	case <-ctx.Done():
+		fmt.Println("wait cron jobs timeout")
+	}
+	return shutdownContainer(container)
+}
+
 // cron进程的启动服务
 var cronStartCommand = &cobra.Command{
 	Use:   "start",
@@ -66,7 +93,7 @@ var cronStartCommand = &cobra.Command{
 		// 获取容器
 		container := cmd.GetContainer()
 		// 获取容器中的app服务
-		appService := container.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](container)
 		// 设置cron的日志地址和进程id地址
 		pidFolder := appService.RuntimeFolder()
 		servicePidFile := filepath.Join(pidFolder,"cron.pid")
@@ -105,7 +132,9 @@ var cronStartCommand = &cobra.Command{
 			defer cntxt.Release()
 			fmt.Println("daemon started")
 			gspt.SetProcTitle("cron")
-			cmd.Root().Cron.Run()
+			if err := runCron(cmd); err != nil {
+				fmt.Println(err)
+			}
 			return nil
 		}
 		// not deamon mode
@@ -117,8 +146,7 @@ var cronStartCommand = &cobra.Command{
 			return err
 		}
 		gspt.SetProcTitle("cron")
-		cmd.Root().Cron.Run()
-		return nil
+		return runCron(cmd)
 	},
 }
 
@@ -127,7 +155,7 @@ var cronStopCommand = &cobra.Command{
 	Short: "停止cron常驻进程",
 	RunE: func(c *cobra.Command, args []string) error {
 		container := c.GetContainer()
-		appService := container.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](container)
 
 		// GetPid
 		serverPidFile := filepath.Join(appService.RuntimeFolder(), "cron.pid")
@@ -159,7 +187,7 @@ var cronRestartCommand = &cobra.Command{
 	Short: "重启cron常驻进程",
 	RunE: func(c *cobra.Command, args []string) error {
 		container := c.GetContainer()
-		appService := container.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](container)
 
 		// GetPid
 		serverPidFile := filepath.Join(appService.RuntimeFolder(), "cron.pid")
@@ -200,7 +228,7 @@ var cronStateCommand = &cobra.Command{
 	Short: "cron常驻进程状态",
 	RunE: func(c *cobra.Command, args []string) error {
 		container := c.GetContainer()
-		appService := container.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](container)
 
 		// GetPid
 		serverPidFile := filepath.Join(appService.RuntimeFolder(), "cron.pid")
@@ -0,0 +1,472 @@
+package command
+
+import (
+	"fmt"
+	"goweb/framework"
+	"goweb/framework/cobra"
+	"goweb/framework/contract"
+	"goweb/framework/provider/config"
+	sshProvider "goweb/framework/provider/ssh"
+	"goweb/framework/util"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultKeepReleases 远端默认保留的发布版本数量
+const defaultKeepReleases = 5
+
+// initDeployCommand 为自动化部署的命令
+func initDeployCommand() *cobra.Command {
+	deployCommand.AddCommand(deployFrontendCommand)
+	deployCommand.AddCommand(deployBackendCommand)
+	deployCommand.AddCommand(deployAllCommand)
+	deployCommand.AddCommand(deployRollbackCommand)
+	return deployCommand
+}
+
+// deployCommand 一键部署到远端服务器
+var deployCommand = &cobra.Command{
+	Use:   "deploy",
+	Short: "部署相关命令",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			c.Help()
+		}
+		return nil
+	},
+}
+
+// deployFrontendCommand 部署前端
+var deployFrontendCommand = &cobra.Command{
+	Use:   "frontend",
+	Short: "部署前端",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		deployFolder, err := createDeployFolder(container)
+		if err != nil {
+			return err
+		}
+		if err := deployBuildFrontend(c, deployFolder); err != nil {
+			return err
+		}
+		return deployUploadFolder(container, deployFolder)
+	},
+}
+
+// deployBackendCommand 部署后端
+var deployBackendCommand = &cobra.Command{
+	Use:   "backend",
+	Short: "部署后端",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		deployFolder, err := createDeployFolder(container)
+		if err != nil {
+			return err
+		}
+		if err := deployBuildBackend(c, deployFolder); err != nil {
+			return err
+		}
+		return deployUploadFolder(container, deployFolder)
+	},
+}
+
+// deployAllCommand 同时部署前端和后端
+var deployAllCommand = &cobra.Command{
+	Use:   "all",
+	Short: "全部部署",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		deployFolder, err := createDeployFolder(container)
+		if err != nil {
+			return err
+		}
+		if err := deployBuildFrontend(c, deployFolder); err != nil {
+			return err
+		}
+		if err := deployBuildBackend(c, deployFolder); err != nil {
+			return err
+		}
+		return deployUploadFolder(container, deployFolder)
+	},
+}
+
+// deployRollbackCommand 回滚到之前的某个版本, 不传参数的时候回滚到上一个版本
+var deployRollbackCommand = &cobra.Command{
+	Use:   "rollback [release]",
+	Short: "部署回滚",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		configService := framework.MustMake[contract.Config](container)
+		remotePath := configService.GetString("deploy.remote_path")
+		if remotePath == "" {
+			return errors.New("deploy.remote_path not set")
+		}
+
+		client, err := getDeploySSHClient(container)
+		if err != nil {
+			return err
+		}
+		sftpClient, err := sftp.NewClient(client)
+		if err != nil {
+			return err
+		}
+		defer sftpClient.Close()
+
+		releases, err := listRemoteReleases(sftpClient, remotePath)
+		if err != nil {
+			return err
+		}
+		current := ""
+		if link, err := sftpClient.ReadLink(path.Join(remotePath, "current")); err == nil {
+			current = path.Base(link)
+		}
+
+		target := ""
+		if len(args) == 1 {
+			for _, release := range releases {
+				if release == args[0] {
+					target = release
+				}
+			}
+			if target == "" {
+				return fmt.Errorf("release %s not exist, available releases: %s", args[0], strings.Join(releases, ","))
+			}
+		} else {
+			target = previousRelease(releases, current)
+			if target == "" {
+				return errors.New("no previous release to rollback, current release: " + current)
+			}
+		}
+
+		fmt.Println("rollback from " + current + " to " + target)
+		if err := switchRemoteRelease(client, remotePath, target); err != nil {
+			return err
+		}
+		return runPostShell(container, client, remotePath)
+	},
+}
+
+// createDeployFolder 创建本次部署的本地目录, 目录名为当前时间, 同时也作为远端的版本号
+func createDeployFolder(container framework.Container) (string, error) {
+	appService := framework.MustMake[contract.App](container)
+	deployFolder := filepath.Join(appService.DeployFolder(), releaseName(time.Now()))
+	if err := os.MkdirAll(deployFolder, os.ModePerm); err != nil {
+		return "", err
+	}
+	return deployFolder, nil
+}
+
+// deployBuildFrontend 编译前端, 并将dist目录拷贝到部署目录
+func deployBuildFrontend(c *cobra.Command, deployFolder string) error {
+	container := c.GetContainer()
+	appService := framework.MustMake[contract.App](container)
+
+	if err := buildFrontendCommand.RunE(c, []string{}); err != nil {
+		return err
+	}
+
+	distFolder := filepath.Join(appService.BaseFolder(), "dist")
+	if !util.Exists(distFolder) {
+		return errors.New("frontend dist folder not exist: " + distFolder)
+	}
+	return util.CopyFolder(distFolder, filepath.Join(deployFolder, "dist"))
+}
+
+// deployBuildBackend 使用deploy.goos和deploy.goarch交叉编译后端, 并拷贝配置文件到部署目录
+func deployBuildBackend(c *cobra.Command, deployFolder string) error {
+	container := c.GetContainer()
+	appService := framework.MustMake[contract.App](container)
+	configService := framework.MustMake[contract.Config](container)
+
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		return errors.New("goweb deploy: please install go in path first")
+	}
+
+	goos := runtime.GOOS
+	if configService.IsExist("deploy.goos") {
+		goos = configService.GetString("deploy.goos")
+	}
+	goarch := runtime.GOARCH
+	if configService.IsExist("deploy.goarch") {
+		goarch = configService.GetString("deploy.goarch")
+	}
+	binFile := "goweb"
+	if goos == "windows" {
+		binFile = "goweb.exe"
+	}
+	fmt.Println("OS: " + goos + " ARCH: " + goarch)
+
+	cmd := exec.Command(goPath, "build", "-o", filepath.Join(deployFolder, binFile), "./")
+	cmd.Dir = appService.BaseFolder()
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Println("go build error:")
+		fmt.Println(string(out))
+		fmt.Println("--------------")
+		return err
+	}
+	fmt.Println("build success: " + filepath.Join(deployFolder, binFile))
+
+	// 拷贝配置文件目录和.env文件
+	if err := util.CopyFolder(appService.ConfigFolder(), filepath.Join(deployFolder, "config")); err != nil {
+		return err
+	}
+	// 本地配置目录config/{env}.local/只在本机生效, 不部署到服务器
+	localFolders, _ := filepath.Glob(filepath.Join(deployFolder, "config", "*.local"))
+	for _, folder := range localFolders {
+		if err := os.RemoveAll(folder); err != nil {
+			return err
+		}
+	}
+	// 配置密钥文件和备份不部署到服务器, 服务器通过环境变量GOWEB_CONFIG_KEY提供密钥
+	for _, file := range []string{config.SecretKeyFile, config.SecretKeyFile + ".bak"} {
+		if err := os.RemoveAll(filepath.Join(deployFolder, "config", file)); err != nil {
+			return err
+		}
+	}
+	envService := framework.MustMake[contract.Env](container)
+	return copyDeployEnvFiles(appService.BaseFolder(), deployFolder, envService.AppEnv())
+}
+
+// copyDeployEnvFiles 拷贝.env和当前环境的.env.{APP_ENV}文件到部署目录
+// .env.local只在本机生效, 不部署到服务器
+func copyDeployEnvFiles(baseFolder, deployFolder, appEnv string) error {
+	for _, file := range []string{".env", ".env." + appEnv} {
+		if file == ".env.local" {
+			continue
+		}
+		envFile := filepath.Join(baseFolder, file)
+		if !util.Exists(envFile) {
+			continue
+		}
+		if err := util.CopyFile(envFile, filepath.Join(deployFolder, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDeploySSHClient 使用deploy.yaml中的配置获取ssh连接
+func getDeploySSHClient(container framework.Container) (*ssh.Client, error) {
+	sshService := framework.MustMake[contract.SSHService](container)
+	return sshService.GetClient(sshProvider.WithConfigPath("deploy"))
+}
+
+// deployUploadFolder 将部署目录上传到远端 remote_path/releases/{版本号}, 切换current到新版本, 执行post_shell, 并清理过期版本
+func deployUploadFolder(container framework.Container, deployFolder string) error {
+	configService := framework.MustMake[contract.Config](container)
+	remotePath := configService.GetString("deploy.remote_path")
+	if remotePath == "" {
+		return errors.New("deploy.remote_path not set")
+	}
+
+	client, err := getDeploySSHClient(container)
+	if err != nil {
+		return err
+	}
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
+
+	release := filepath.Base(deployFolder)
+	releaseFolder := remoteReleaseFolder(remotePath, release)
+	if err := sftpClient.MkdirAll(releaseFolder); err != nil {
+		return err
+	}
+
+	// 只部署前端或者后端的时候, 以当前版本为基础, 保留另一部分的文件
+	if _, err := sftpClient.Stat(path.Join(remotePath, "current")); err == nil {
+		copyCmd := fmt.Sprintf("cp -a %s/. %s/", shellQuote(path.Join(remotePath, "current")), shellQuote(releaseFolder))
+		if _, err := runRemoteShell(client, copyCmd); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("upload " + deployFolder + " to " + releaseFolder)
+	if err := uploadFolder(sftpClient, deployFolder, releaseFolder); err != nil {
+		return err
+	}
+
+	if err := switchRemoteRelease(client, remotePath, release); err != nil {
+		return err
+	}
+	if err := runPostShell(container, client, remotePath); err != nil {
+		return err
+	}
+
+	keep := defaultKeepReleases
+	if configService.IsExist("deploy.keep_releases") {
+		keep = configService.GetInt("deploy.keep_releases")
+	}
+	return pruneRemoteReleases(client, sftpClient, remotePath, keep)
+}
+
+// uploadFolder 将本地目录上传到远端目录, 保留文件权限
+func uploadFolder(sftpClient *sftp.Client, localFolder, remoteFolder string) error {
+	return filepath.Walk(localFolder, func(localFile string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localFolder, localFile)
+		if err != nil {
+			return err
+		}
+		remoteFile := path.Join(remoteFolder, filepath.ToSlash(rel))
+		if info.IsDir() {
+			return sftpClient.MkdirAll(remoteFile)
+		}
+
+		src, err := os.Open(localFile)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := sftpClient.Create(remoteFile)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		if _, err := io.Copy(dst, src); err != nil {
+			return err
+		}
+		fmt.Println("upload file: " + remoteFile)
+		return sftpClient.Chmod(remoteFile, info.Mode())
+	})
+}
+
+// listRemoteReleases 获取远端所有的版本号, 从旧到新排序
+func listRemoteReleases(sftpClient *sftp.Client, remotePath string) ([]string, error) {
+	infos, err := sftpClient.ReadDir(path.Join(remotePath, "releases"))
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() {
+			releases = append(releases, info.Name())
+		}
+	}
+	sort.Strings(releases)
+	return releases, nil
+}
+
+// releaseName 部署的版本号, 使用部署时间, 按照字符串排序即为发布的先后顺序
+func releaseName(t time.Time) string {
+	return t.Format("20060102150405")
+}
+
+// remoteReleaseFolder 远端某个版本的目录 remote_path/releases/{版本号}
+func remoteReleaseFolder(remotePath, release string) string {
+	return path.Join(remotePath, "releases", release)
+}
+
+// switchReleaseShell 将远端的current软链指向某个版本的命令, -n保证current已经存在的时候替换软链本身
+func switchReleaseShell(remotePath, release string) string {
+	return fmt.Sprintf("ln -sfn %s %s", shellQuote(remoteReleaseFolder(remotePath, release)), shellQuote(path.Join(remotePath, "current")))
+}
+
+// switchRemoteRelease 将远端的current软链指向某个版本
+func switchRemoteRelease(client *ssh.Client, remotePath, release string) error {
+	_, err := runRemoteShell(client, switchReleaseShell(remotePath, release))
+	return err
+}
+
+// previousRelease 当前版本的上一个版本, 没有的时候返回空, releases需要从旧到新排序
+func previousRelease(releases []string, current string) string {
+	for i, release := range releases {
+		if release == current && i > 0 {
+			return releases[i-1]
+		}
+	}
+	return ""
+}
+
+// expiredReleases 只保留最新的keep个版本时需要删除的版本, current指向的版本永远不删除, keep小于等于0表示全部保留
+func expiredReleases(releases []string, current string, keep int) []string {
+	if keep <= 0 || len(releases) <= keep {
+		return nil
+	}
+	expired := make([]string, 0, len(releases)-keep)
+	for _, release := range releases[:len(releases)-keep] {
+		if release == current {
+			continue
+		}
+		expired = append(expired, release)
+	}
+	return expired
+}
+
+// pruneRemoteReleases 只保留最新的keep个版本, current指向的版本永远不删除
+func pruneRemoteReleases(client *ssh.Client, sftpClient *sftp.Client, remotePath string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	releases, err := listRemoteReleases(sftpClient, remotePath)
+	if err != nil {
+		return err
+	}
+	current := ""
+	if link, err := sftpClient.ReadLink(path.Join(remotePath, "current")); err == nil {
+		current = path.Base(link)
+	}
+	for _, release := range expiredReleases(releases, current, keep) {
+		fmt.Println("remove release: " + release)
+		rmCmd := "rm -rf " + shellQuote(remoteReleaseFolder(remotePath, release))
+		if _, err := runRemoteShell(client, rmCmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPostShell 在远端的current目录下依次执行deploy.post_shell中的命令
+func runPostShell(container framework.Container, client *ssh.Client, remotePath string) error {
+	configService := framework.MustMake[contract.Config](container)
+	for _, shell := range configService.GetStringSlice("deploy.post_shell") {
+		if shell == "" {
+			continue
+		}
+		fmt.Println("execute: " + shell)
+		out, err := runRemoteShell(client, "cd "+shellQuote(path.Join(remotePath, "current"))+" && "+shell)
+		fmt.Print(out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRemoteShell 在远端执行一条命令, 返回命令的输出
+func runRemoteShell(client *ssh.Client, shell string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	out, err := session.CombinedOutput(shell)
+	if err != nil {
+		return string(out), errors.Wrap(err, "execute remote shell error: "+shell)
+	}
+	return string(out), nil
+}
+
+// shellQuote 使用单引号包裹参数, 防止路径中的特殊字符被shell解析
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
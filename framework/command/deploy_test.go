@@ -0,0 +1,63 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploy_ReleasePath(t *testing.T) {
+	release := releaseName(time.Date(2022, 10, 8, 9, 5, 3, 0, time.Local))
+	assert.Equal(t, "20221008090503", release)
+	// 版本号按照字符串排序即为时间顺序
+	assert.Less(t, release, releaseName(time.Date(2022, 10, 8, 10, 0, 0, 0, time.Local)))
+
+	assert.Equal(t, "/home/web/releases/20221008090503", remoteReleaseFolder("/home/web/", release))
+	assert.Equal(t, "ln -sfn '/home/web/releases/20221008090503' '/home/web/current'", switchReleaseShell("/home/web", release))
+	assert.Equal(t, "ln -sfn '/it'\\''s/releases/1' '/it'\\''s/current'", switchReleaseShell("/it's", "1"))
+}
+
+func TestDeploy_PreviousRelease(t *testing.T) {
+	releases := []string{"1", "2", "3"}
+	assert.Equal(t, "2", previousRelease(releases, "3"))
+	assert.Equal(t, "1", previousRelease(releases, "2"))
+	assert.Equal(t, "", previousRelease(releases, "1"))
+	assert.Equal(t, "", previousRelease(releases, "not_exist"))
+}
+
+func TestDeploy_ExpiredReleases(t *testing.T) {
+	releases := []string{"1", "2", "3", "4", "5"}
+	assert.Equal(t, []string{"1", "2"}, expiredReleases(releases, "5", 3))
+	// current指向的旧版本不删除, 即使超过了保留的数量
+	assert.Equal(t, []string{"1"}, expiredReleases(releases, "2", 3))
+	assert.Empty(t, expiredReleases(releases, "5", 5))
+	assert.Empty(t, expiredReleases(releases, "5", 10))
+	// keep小于等于0表示全部保留
+	assert.Empty(t, expiredReleases(releases, "5", 0))
+	assert.Empty(t, expiredReleases(releases, "5", -1))
+}
+
+func TestDeploy_CopyEnvFiles(t *testing.T) {
+	baseFolder := t.TempDir()
+	for _, file := range []string{".env", ".env.production", ".env.testing", ".env.local"} {
+		require.NoError(t, os.WriteFile(filepath.Join(baseFolder, file), []byte("APP_NAME="+file), 0644))
+	}
+
+	deployFolder := t.TempDir()
+	require.NoError(t, copyDeployEnvFiles(baseFolder, deployFolder, "production"))
+	assert.FileExists(t, filepath.Join(deployFolder, ".env"))
+	assert.FileExists(t, filepath.Join(deployFolder, ".env.production"))
+	assert.NoFileExists(t, filepath.Join(deployFolder, ".env.testing"))
+	assert.NoFileExists(t, filepath.Join(deployFolder, ".env.local"))
+
+	// .env.local即使和环境同名也不部署, 对应环境的文件不存在的时候跳过
+	deployFolder = t.TempDir()
+	require.NoError(t, copyDeployEnvFiles(baseFolder, deployFolder, "local"))
+	assert.FileExists(t, filepath.Join(deployFolder, ".env"))
+	assert.NoFileExists(t, filepath.Join(deployFolder, ".env.local"))
+	require.NoError(t, copyDeployEnvFiles(baseFolder, t.TempDir(), "development"))
+}
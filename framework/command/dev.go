@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -51,7 +52,7 @@ func initDevConfig(c framework.Container) *devConfig {
 			"8071",
 		},
 	}
-	configer := c.MustMake(contract.ConfigKey).(contract.Config)
+	configer := framework.MustMake[contract.Config](c)
 	if configer.IsExist("app.dev.port") {
 		devConfig.Port = configer.GetString("app.dev.port")
 	}
@@ -63,7 +64,7 @@ func initDevConfig(c framework.Container) *devConfig {
 	}
 	monitorFolder := configer.GetString("app.dev.backend.monitor_folder")
 	if monitorFolder == "" {
-		appService := c.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](c)
 		devConfig.Backend.MonitorFolder = appService.AppFolder()
 	}
 
@@ -79,6 +80,11 @@ type Proxy struct {
 	devConfig   *devConfig   // 配置文件
 	backendPid  int          // 当前的backend服务的pid
 	frontendPid int          // 当前的frontend服务的pid
+
+	container    framework.Container    // 服务容器, 配置变化的时候重新读取配置
+	lock         sync.Mutex             // 保护devConfig, reverseProxy和server
+	reverseProxy *httputil.ReverseProxy // 当前使用的反向代理
+	server       *http.Server           // 当前的代理服务
 }
 
 // NewProxy 初始化一个Proxy
@@ -86,7 +92,70 @@ func NewProxy(c framework.Container) *Proxy {
 	devConfig := initDevConfig(c)
 	return &Proxy{
 		devConfig: devConfig,
+		container: c,
+	}
+}
+
+// ServeHTTP 使用当前的反向代理转发请求, 配置变化之后转发到新的端口
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	reverseProxy := p.reverseProxy
+	p.lock.Unlock()
+	if reverseProxy == nil {
+		http.Error(w, "前端和后端服务都不存在", http.StatusBadGateway)
+		return
 	}
+	reverseProxy.ServeHTTP(w, r)
+}
+
+// resetReverseProxy 根据当前配置的端口重新创建反向代理
+func (p *Proxy) resetReverseProxy() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	frontendUrl, err := url.Parse(fmt.Sprintf("%s%s", "http://127.0.0.1:", p.devConfig.Frontend.Port))
+	if err != nil {
+		return err
+	}
+	backendUrl, err := url.Parse(fmt.Sprintf("%s%s", "http://127.0.0.1:", p.devConfig.Backend.Port))
+	if err != nil {
+		return err
+	}
+	p.reverseProxy = p.NewProxyReverseProxy(frontendUrl, backendUrl)
+	return nil
+}
+
+// watchConfig 监听app.dev配置的变化, 端口变化的时候重启对应的服务
+func (p *Proxy) watchConfig(startFrontend, startBackend bool) {
+	configService := framework.MustMake[contract.Config](p.container)
+	configService.Watch("app.dev", func(old, new interface{}) {
+		devConfig := initDevConfig(p.container)
+		p.lock.Lock()
+		oldConfig := p.devConfig
+		p.devConfig = devConfig
+		server := p.server
+		p.lock.Unlock()
+
+		if startBackend && oldConfig.Backend.Port != devConfig.Backend.Port {
+			fmt.Println("后端端口变更:", oldConfig.Backend.Port, "->", devConfig.Backend.Port)
+			if err := p.restartBackend(); err != nil {
+				fmt.Println("重新启动后端失败：", err.Error())
+			}
+		}
+		if startFrontend && oldConfig.Frontend.Port != devConfig.Frontend.Port {
+			fmt.Println("前端端口变更:", oldConfig.Frontend.Port, "->", devConfig.Frontend.Port)
+			if err := p.restartFrontend(); err != nil {
+				fmt.Println("重新启动前端失败：", err.Error())
+			}
+		}
+		if err := p.resetReverseProxy(); err != nil {
+			fmt.Println("重新设置代理失败：", err.Error())
+		}
+		// 代理端口变化的时候关闭当前的代理服务, startProxy会使用新的端口重新启动
+		if oldConfig.Port != devConfig.Port && server != nil {
+			fmt.Println("代理端口变更:", oldConfig.Port, "->", devConfig.Port)
+			server.Close()
+		}
+	})
 }
 
 // 重新启动一个proxy网关
@@ -158,8 +227,10 @@ func (p *Proxy) restartBackend() error {
 		syscall.Kill(p.backendPid, syscall.SIGKILL)
 	}
 	// 设置随机端口，真实后端的端口
+	p.lock.Lock()
 	port := p.devConfig.Backend.Port
-	address := fmt.Sprintf(":", port)
+	p.lock.Unlock()
+	address := ":" + port
 	// 使用命令行启动后端进程
 	cmd := exec.Command("./goweb", "app", "start", "--address="+address)
 	cmd.Stdout = os.NewFile(0, os.DevNull)
@@ -178,20 +249,22 @@ func (p *Proxy) restartBackend() error {
 func (p *Proxy) restartFrontend() error {
 	// 启动前端调试模式
 	// 如果已经开启了npm run serve， 什么都不做
-	if p.frontendPid == 0 {
+	if p.frontendPid != 0 {
 		syscall.Kill(p.frontendPid, syscall.SIGKILL)
 		p.frontendPid = 0
 	}
 
 	// 否则开启npm run serve
+	p.lock.Lock()
 	port := p.devConfig.Frontend.Port
+	p.lock.Unlock()
 	path, err := exec.LookPath("npm")
 	if err != nil {
 		return err
 	}
 	cmd := exec.Command(path, "run", "dev")
 	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, fmt.Sprint("%s%s", "PORT=", port))
+	cmd.Env = append(cmd.Env, "PORT="+port)
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.NewFile(0, os.DevNull)
 
@@ -200,7 +273,7 @@ func (p *Proxy) restartFrontend() error {
 	err = cmd.Start()
 	fmt.Println("启动前端服务: ", "http://127.0.0.1:"+port)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	p.frontendPid = cmd.Process.Pid
 	fmt.Println("前端服务pid:", p.frontendPid)
@@ -209,9 +282,6 @@ func (p *Proxy) restartFrontend() error {
 
 // 重启后端服务, 如果frontend为nil，则没有包含后端
 func (p *Proxy) startProxy(startFrontend, startBackend bool) error {
-	var backendUrl, frontendUrl *url.URL
-	var err error
-	
 	//启动后端
 	if startBackend {
 		if err := p.restartBackend();err != nil {
@@ -226,29 +296,32 @@ func (p *Proxy) startProxy(startFrontend, startBackend bool) error {
 		}
 	}
 
-
-	
-	if frontendUrl, err = url.Parse(fmt.Sprintf("%s%s", "http://127.0.0.1:", p.devConfig.Frontend.Port)); err != nil {
-		return err
-	}
-
-	if backendUrl, err = url.Parse(fmt.Sprintf("%s%s", "http://127.0.0.1:", p.devConfig.Backend.Port)); err != nil {
+	// 设置反向代理
+	if err := p.resetReverseProxy(); err != nil {
 		return err
 	}
+	p.watchConfig(startFrontend, startBackend)
 
-	// 设置反向代理
-	proxyReverse := p.NewProxyReverseProxy(frontendUrl,backendUrl)
-	proxyServer := &http.Server{
-		Addr:  "127.0.0.1:" + p.devConfig.Port,
-		Handler: proxyReverse,
-	}
-	fmt.Println("代理服务启动:", "http://"+proxyServer.Addr)
-	// 启动proxy服务
-	err = proxyServer.ListenAndServe()
-	if err != nil {
-		fmt.Println(err)
+	for {
+		p.lock.Lock()
+		proxyServer := &http.Server{
+			Addr:  "127.0.0.1:" + p.devConfig.Port,
+			Handler: p,
+		}
+		p.server = proxyServer
+		p.lock.Unlock()
+
+		fmt.Println("代理服务启动:", "http://"+proxyServer.Addr)
+		// 启动proxy服务, 代理端口变化的时候会被关闭, 使用新的端口重新启动
+		err := proxyServer.ListenAndServe()
+		if errors.Is(err, http.ErrServerClosed) {
+			continue
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+		return nil
 	}
-	return nil
 }
 
 
@@ -303,7 +376,6 @@ func (p *Proxy) monitorBackend() error {
 			t.Reset(time.Duration(refreshTime) * time.Second)
 		}
 	}
-	return nil
 }
 
 // 初始化Dev命令
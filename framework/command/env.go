@@ -2,14 +2,21 @@ package command
 
 import (
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
+	"goweb/framework/provider/config"
 	"goweb/framework/util"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // initEnvCommand 获取env相关的命令
 func initEnvCommand() *cobra.Command {
+	envListCommand.Flags().BoolVar(&envReveal, "reveal", false, "显示密码, 密钥等敏感环境变量的值")
 	envCommand.AddCommand(envListCommand)
+	envCommand.AddCommand(envCheckCommand)
 	return envCommand
 }
 
@@ -20,7 +27,7 @@ var envCommand = &cobra.Command{
 	Run: func(c *cobra.Command, args []string) {
 		// 获取env环境
 		container := c.GetContainer()
-		envService := container.MustMake(contract.EnvKey).(contract.Env)
+		envService := framework.MustMake[contract.Env](container)
 		// 打印环境
 		fmt.Println("environment:", envService.AppEnv())
 	},
@@ -33,12 +40,114 @@ var envListCommand = &cobra.Command{
 	Run: func(c *cobra.Command, args []string) {
 		// 获取env环境
 		container := c.GetContainer()
-		envService := container.MustMake(contract.EnvKey).(contract.Env)
+		envService := framework.MustMake[contract.Env](container)
 		envs := envService.All()
 		outs := [][]string{}
 		for k, v := range envs {
+			// 敏感的环境变量默认隐藏
+			if !envReveal && v != "" && isSecretName(k) {
+				v = redactedValue
+			}
 			outs = append(outs, []string{k, v})
 		}
 		util.PrettyPrint(outs)
 	},
-}
\ No newline at end of file
+}
+// envReveal 是否显示敏感环境变量的值
+var envReveal = false
+
+// secretNameWords 环境变量或者配置项名称中包含这些词的时候认为是敏感的
+var secretNameWords = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL"}
+
+// isSecretName 判断是否为敏感的环境变量或者配置项, 比如配置密钥GOWEB_CONFIG_KEY, 配置文件中引用的DB_PASSWORD
+func isSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range secretNameWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// envCheckCommand 检查配置文件中引用了, 但是在.env文件和运行环境中都没有定义的环境变量
+var envCheckCommand = &cobra.Command{
+	Use:          "check",
+	Short:        "检查配置文件中引用了但是没有定义的环境变量",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		appService := framework.MustMake[contract.App](container)
+		envService := framework.MustMake[contract.Env](container)
+
+		ps := [][]string{{"variable", "file", "note"}}
+		required := 0
+		err := filepath.Walk(appService.ConfigFolder(), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !config.IsConfigFile(info.Name()) {
+				return nil
+			}
+			bf, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			file := path
+			if rel, err := filepath.Rel(appService.BaseFolder(), path); err == nil {
+				file = rel
+			}
+			for _, ref := range config.EnvReferences(bf) {
+				note, missing := envReferenceNote(ref, envService.IsExist(ref.Name), envService.Get(ref.Name))
+				if note == "" {
+					continue
+				}
+				if missing {
+					required++
+				}
+				ps = append(ps, []string{ref.Name, fmt.Sprintf("%s:%d", file, ref.Line), note})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(ps) == 1 {
+			fmt.Println("配置文件中引用的环境变量都已经定义")
+			return nil
+		}
+		util.PrettyPrint(ps)
+		// 没有默认值的环境变量未定义或者为空的时候返回错误, 方便CI判断
+		if required > 0 {
+			return fmt.Errorf("%d 个环境变量没有定义或者为空", required)
+		}
+		return nil
+	},
+}
+
+// envReferenceNote 按照加载配置文件时的替换规则, 判断引用的环境变量是否需要提示, 不需要提示的时候返回空
+// 第二个返回值表示环境变量缺失, 没有默认值可以使用
+func envReferenceNote(ref config.EnvReference, exist bool, val string) (string, bool) {
+	switch {
+	case ref.Legacy:
+		// env(KEY)只判断是否定义, 没有默认值, 未定义的时候配置中保留env(KEY)原样
+		if !exist {
+			return "未定义, 配置的值为 env(" + ref.Name + ") 原样", true
+		}
+		if val == "" {
+			return "值为空", true
+		}
+	case val != "":
+		return "", false
+	case ref.Required:
+		// ${KEY:?message}中环境变量为空和未定义一样会导致启动失败
+		return "必须的环境变量, 未定义或者为空, 启动会失败", true
+	case ref.Default:
+		return "未定义或者为空, 使用默认值", false
+	case exist:
+		return "值为空", true
+	default:
+		return "未定义, 值为空", true
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"goweb/framework/provider/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnv_ReferenceNote(t *testing.T) {
+	cases := []struct {
+		ref     config.EnvReference
+		exist   bool
+		val     string
+		note    string
+		missing bool
+	}{
+		{config.EnvReference{Name: "A"}, true, "1", "", false},
+		{config.EnvReference{Name: "A"}, true, "", "值为空", true},
+		{config.EnvReference{Name: "A"}, false, "", "未定义, 值为空", true},
+		{config.EnvReference{Name: "A", Required: true}, true, "1", "", false},
+		// 和加载配置文件一样, 必须的环境变量为空的时候也会启动失败
+		{config.EnvReference{Name: "A", Required: true}, true, "", "必须的环境变量, 未定义或者为空, 启动会失败", true},
+		{config.EnvReference{Name: "A", Required: true}, false, "", "必须的环境变量, 未定义或者为空, 启动会失败", true},
+		{config.EnvReference{Name: "A", Default: true}, true, "1", "", false},
+		{config.EnvReference{Name: "A", Default: true}, true, "", "未定义或者为空, 使用默认值", false},
+		{config.EnvReference{Name: "A", Default: true}, false, "", "未定义或者为空, 使用默认值", false},
+		// env(KEY)没有默认值, 未定义的时候保留原样
+		{config.EnvReference{Name: "A", Legacy: true}, true, "1", "", false},
+		{config.EnvReference{Name: "A", Legacy: true}, true, "", "值为空", true},
+		{config.EnvReference{Name: "A", Legacy: true}, false, "", "未定义, 配置的值为 env(A) 原样", true},
+	}
+	for _, c := range cases {
+		note, missing := envReferenceNote(c.ref, c.exist, c.val)
+		assert.Equal(t, c.note, note, "%+v exist=%v val=%q", c.ref, c.exist, c.val)
+		assert.Equal(t, c.missing, missing, "%+v exist=%v val=%q", c.ref, c.exist, c.val)
+	}
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/contract"
 
 	"goweb/framework/cobra"
@@ -13,7 +14,7 @@ var DemoCommand = &cobra.Command{
 	Short: "demo for framework",
 	Run: func(c *cobra.Command, args []string) {
 		container := c.GetContainer()
-		appService := container.MustMake(contract.AppKey).(contract.App)
+		appService := framework.MustMake[contract.App](container)
 		fmt.Println("app base folder:", appService.BaseFolder())
 	},
 }
\ No newline at end of file
@@ -6,8 +6,8 @@ import "goweb/framework/cobra"
 func AddKernelCommands(root *cobra.Command) {
 	// root.AddCommand(DemoCommand)
 	root.AddCommand(initEnvCommand())
-	root.AddCommand(initEnvCommand())
-	//root.AddCommand(deployCommand)
+	// deploy
+	root.AddCommand(initDeployCommand())
 	// config 命令
 	root.AddCommand(initConfigCommand())
 	//// cron
@@ -37,10 +37,8 @@ func AddKernelCommands(root *cobra.Command) {
 	//middlewareCommand.AddCommand(middlewareRemoveCommand)
 	root.AddCommand(initMiddlewareCommand())
 	//
-	//// swagger
-	//swagger.IndexCommand.AddCommand(swagger.InitServeCommand())
-	//swagger.IndexCommand.AddCommand(swagger.GenCommand)
-	//root.AddCommand(swagger.IndexCommand)
+	// swagger
+	root.AddCommand(initSwaggerCommand())
 	//
 	//// provider
 	root.AddCommand(initProviderCommand())
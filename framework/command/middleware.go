@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"fmt"
+	"goweb/framework"
 	"goweb/framework/cobra"
 	"goweb/framework/contract"
 	"goweb/framework/util"
@@ -45,7 +46,7 @@ var middlewareAllCommand = &cobra.Command{
     Short: "显示所有中间件",
     RunE: func(c *cobra.Command, args []string) error {
         container := c.GetContainer()
-        appService := container.MustMake(contract.AppKey).(contract.App)
+        appService := framework.MustMake[contract.App](container)
 
         middlewarePath := path.Join(appService.BaseFolder(), "app", "http", "middleware")
 
@@ -81,7 +82,7 @@ var middlewareMigrateCommand = &cobra.Command{
             }
         }
         // step2 : 下载git到一个目录中
-        appService := container.MustMake(contract.AppKey).(contract.App)
+        appService := framework.MustMake[contract.App](container)
 
         middlewarePath := appService.MiddlewareFolder()
         url := "https://github.com/gin-contrib/" + repo + ".git"
@@ -167,7 +168,7 @@ var middlewareCreateCommand = &cobra.Command{
             folder = name
         }
 
-        app := container.MustMake(contract.AppKey).(contract.App)
+        app := framework.MustMake[contract.App](container)
 
         pFolder := app.MiddlewareFolder()
         subFolders, err := util.SubDir(pFolder)
@@ -221,7 +222,7 @@ var middlewareMigrateThirdCommand = &cobra.Command{
             }
         }
         // step2 : 下载git到一个目录中
-        appService := container.MustMake(contract.AppKey).(contract.App)
+        appService := framework.MustMake[contract.App](container)
 
         middlewarePath := appService.MiddlewareFolder()
         url := repo
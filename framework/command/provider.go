@@ -8,6 +8,7 @@ import (
 	"goweb/framework/util"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -19,6 +20,9 @@ import (
 // 初始化provider相关服务
 func initProviderCommand() *cobra.Command {
 	providerCommand.AddCommand(providerCreateCommand)
+	providerListCommand.Flags().BoolVar(&providerListGraph, "graph", false, "输出服务之间的依赖关系")
+	providerListCommand.Flags().StringVar(&providerListFormat, "format", "text", "依赖关系的输出格式, 支持text和dot")
+	providerListCommand.Flags().BoolVar(&providerListResolve, "resolve", false, "先实例化所有服务, 获取完整的依赖关系")
 	providerCommand.AddCommand(providerListCommand)
 	return providerCommand
 }
@@ -33,20 +37,82 @@ var providerCommand = &cobra.Command{
         return nil
     },
 }
+// providerListGraph 是否输出服务之间的依赖关系
+var providerListGraph = false
+
+// providerListFormat 依赖关系的输出格式, text或者dot
+var providerListFormat = "text"
+
+// providerListResolve 是否先实例化所有服务, 以获取完整的依赖关系
+var providerListResolve = false
+
 // providerListCommand 列出容器内的所有服务
 var providerListCommand = &cobra.Command{
-    Use:   "list",
-    Short: "列出容器内的所有服务",
-    RunE: func(c *cobra.Command, args []string) error {
-        con := c.GetContainer()
-        Container := con.(*framework.ServiceContainer)
-        list := Container.NameList()
-        for _, line := range list {
-            println(line)
-        }
-        return nil
-    },
+	Use:   "list",
+	Short: "列出容器内的所有服务",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer().(*framework.ServiceContainer)
+		if providerListResolve {
+			if err := resolveProviders(container); err != nil {
+				return err
+			}
+		}
+		infos := container.ProviderInfos()
+
+		if !providerListGraph {
+			ps := [][]string{{"name", "defer", "instantiated"}}
+			for _, info := range infos {
+				ps = append(ps, []string{info.Name, strconv.FormatBool(info.IsDefer), strconv.FormatBool(info.Instantiated)})
+			}
+			util.PrettyPrint(ps)
+			return nil
+		}
+
+		switch providerListFormat {
+		case "text":
+			for _, info := range infos {
+				fmt.Printf("%s (defer=%t, instantiated=%t)\n", info.Name, info.IsDefer, info.Instantiated)
+				for _, dep := range info.Dependencies {
+					fmt.Println("  -> " + dep)
+				}
+			}
+		case "dot":
+			fmt.Println("digraph container {")
+			for _, info := range infos {
+				style := "solid"
+				if !info.Instantiated {
+					style = "dashed"
+				}
+				fmt.Printf("  %q [label=%q, style=%q];\n", info.Name, fmt.Sprintf("%s\ndefer=%t", info.Name, info.IsDefer), style)
+			}
+			for _, info := range infos {
+				for _, dep := range info.Dependencies {
+					fmt.Printf("  %q -> %q;\n", info.Name, dep)
+				}
+			}
+			fmt.Println("}")
+		default:
+			return errors.New("unknown format: " + providerListFormat + ", support text and dot")
+		}
+		return nil
+	},
+}
+
+// resolveProviders 实例化容器中的所有服务, 作用域服务不能直接从容器中获取, 在一个临时的作用域中实例化, 结束之后关闭这个作用域
+func resolveProviders(container *framework.ServiceContainer) error {
+	scope, err := framework.NewScope(container)
+	if err != nil {
+		return err
+	}
+	defer scope.Close()
+	for _, name := range container.NameList() {
+		if _, err := scope.Make(name); err != nil {
+			fmt.Println("make", name, "error:", err)
+		}
+	}
+	return nil
 }
+
 // providerCreateCommand 创建一个新的服务，包括服务提供者，服务接口协议，服务实例
 var providerCreateCommand = &cobra.Command{
 	Use:     "new",
@@ -88,7 +154,7 @@ var providerCreateCommand = &cobra.Command{
 			folder = name
 		}
 
-		app := container.MustMake(contract.AppKey).(contract.App)
+		app := framework.MustMake[contract.App](container)
 
 		pFolder := app.ProviderFolder()
 		subFolders, err := util.SubDir(pFolder)
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/app"
+	"goweb/framework/provider/config"
+	"goweb/framework/provider/env"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRequestProvider 测试使用的作用域服务, 依赖配置服务, 作用域结束的时候关闭
+type testRequestProvider struct {
+	closed *int
+}
+
+type testRequest struct {
+	closed *int
+}
+
+func (r *testRequest) Close() error {
+	*r.closed++
+	return nil
+}
+
+func (p *testRequestProvider) Register(c framework.Container) framework.NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		container := params[0].(framework.Container)
+		if _, err := container.Make(contract.ConfigKey); err != nil {
+			return nil, err
+		}
+		return &testRequest{closed: p.closed}, nil
+	}
+}
+func (p *testRequestProvider) Boot(c framework.Container) error { return nil }
+func (p *testRequestProvider) IsDefer() bool                    { return true }
+func (p *testRequestProvider) IsScoped() bool                   { return true }
+func (p *testRequestProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c}
+}
+func (p *testRequestProvider) Name() string { return "test:request" }
+
+func TestProvider_Resolve(t *testing.T) {
+	t.Setenv("APP_ENV", contract.EnvTesting)
+	baseFolder := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(baseFolder, "config", contract.EnvTesting), os.ModePerm))
+	closed := 0
+	container := framework.NewContainer()
+	require.NoError(t, container.Bind(&app.AppProvider{BaseFolder: baseFolder}))
+	require.NoError(t, container.Bind(&env.EnvProvider{}))
+	require.NoError(t, container.Bind(&config.ConfigProvider{}))
+	require.NoError(t, container.Bind(&testRequestProvider{closed: &closed}))
+
+	// 作用域服务在临时的作用域中实例化, 依赖关系记录到容器中, 结束之后关闭
+	require.NoError(t, resolveProviders(container))
+	assert.Equal(t, 1, closed)
+	for _, info := range container.ProviderInfos() {
+		if info.Name == "test:request" {
+			assert.Equal(t, []string{contract.ConfigKey}, info.Dependencies)
+			assert.False(t, info.Instantiated)
+		} else {
+			assert.True(t, info.Instantiated, info.Name)
+		}
+	}
+}
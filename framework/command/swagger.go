@@ -1,3 +1,165 @@
 package command
 
+import (
+	"context"
+	"fmt"
+	"goweb/framework"
+	"goweb/framework/cobra"
+	"goweb/framework/contract"
+	"goweb/framework/gin"
+	"goweb/framework/middleware/swagger"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/pkg/errors"
+	swaggerFiles "github.com/swaggo/files"
+	"github.com/swaggo/swag"
+	"github.com/swaggo/swag/gen"
+)
+
+// initSwaggerCommand 初始化swagger命令和其子命令
+func initSwaggerCommand() *cobra.Command {
+	swaggerCommand.AddCommand(swaggerGenCommand)
+	swaggerCommand.AddCommand(swaggerServeCommand)
+	return swaggerCommand
+}
+
+// swaggerCommand 是命令行参数第一级为swagger的命令，它没有实际功能，只是打印帮助文档
+var swaggerCommand = &cobra.Command{
+	Use:   "swagger",
+	Short: "swagger对应命令",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			c.Help()
+		}
+		return nil
+	},
+}
+
+// swaggerGenCommand 解析HttpFolder下的注释, 生成swagger文档到HttpFolder/swagger
+var swaggerGenCommand = &cobra.Command{
+	Use:   "gen",
+	Short: "生成对应的swagger文件, contain swagger.yaml, doc.go",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		appService := framework.MustMake[contract.App](container)
+
+		conf := swaggerGenConfig(appService.HttpFolder())
+		if err := gen.New().Build(conf); err != nil {
+			return err
+		}
+		fmt.Println("swagger generate success: " + conf.OutputDir)
+		return nil
+	},
+}
+
+// swaggerGenConfig 生成swagger文档的配置, 解析httpFolder下的注释, 输出到httpFolder/swagger
+func swaggerGenConfig(httpFolder string) *gen.Config {
+	return &gen.Config{
+		// 遍历需要查询注释的目录
+		SearchDir: httpFolder,
+		// 不包含哪些文件
+		Excludes: "",
+		// 输出目录
+		OutputDir: filepath.Join(httpFolder, "swagger"),
+		// 输出的文件类型
+		OutputTypes: []string{"go", "json", "yaml"},
+		// 整个swagger接口的说明文档注释, 相对于SearchDir
+		MainAPIFile: "swagger.go",
+		// 名字的显示策略，比如首字母大写等
+		PropNamingStrategy: swag.CamelCase,
+		// 是否要解析vendor目录
+		ParseVendor: false,
+		// 是否要解析外部依赖库的包
+		ParseDependency: false,
+		// 是否要解析标准库的包
+		ParseInternal: false,
+		// 依赖的解析深度
+		ParseDepth: 100,
+		// 是否生成时间
+		GeneratedTime: false,
+	}
+}
+
+// swaggerServeCommand 在swagger.url配置的地址启动一个swagger ui服务, 文档读取HttpFolder/swagger/swagger.json
+var swaggerServeCommand = &cobra.Command{
+	Use:   "serve",
+	Short: "启动一个swagger ui服务",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		appService := framework.MustMake[contract.App](container)
+		configService := framework.MustMake[contract.Config](container)
+
+		docFile := filepath.Join(appService.HttpFolder(), "swagger", "swagger.json")
+		if _, err := os.Stat(docFile); err != nil {
+			return errors.New("swagger doc not exist, please run ./goweb swagger gen first")
+		}
+
+		serveURL := configService.GetString("swagger.url")
+		if serveURL == "" {
+			serveURL = "http://127.0.0.1:8069"
+		}
+		addr, err := swaggerServeAddr(serveURL)
+		if err != nil {
+			return err
+		}
+		server := &http.Server{
+			Addr:    addr,
+			Handler: swaggerServeEngine(docFile),
+		}
+
+		fmt.Println("swagger ui: " + strings.TrimSuffix(serveURL, "/") + "/swagger/index.html")
+
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+		defer signal.Stop(quit)
+		return swaggerServe(server, quit)
+	},
+}
+
+// swaggerServe 启动服务, 直到收到quit信号后优雅关闭, 服务启动失败(比如端口被占用)的时候返回错误
+func swaggerServe(server *http.Server, quit <-chan os.Signal) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return errors.Wrap(err, "swagger serve error")
+	case <-quit:
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(timeoutCtx)
+}
+
+// swaggerServeAddr 根据swagger.url获取监听的地址, 地址中必须包含端口, 保留其中的host, 比如127.0.0.1只监听本机
+func swaggerServeAddr(serveURL string) (string, error) {
+	u, err := url.Parse(serveURL)
+	if err != nil {
+		return "", errors.Wrap(err, "swagger.url error")
+	}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return "", errors.Wrap(err, "swagger.url should contain port")
+	}
+	return u.Host, nil
+}
+
+// swaggerServeEngine swagger ui服务的路由, 文档读取docFile, 根路径跳转到ui首页
+func swaggerServeEngine(docFile string) *gin.Engine {
+	engine := gin.New()
+	engine.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler, swagger.DocFile(docFile)))
+	engine.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/swagger/index.html")
+	})
+	return engine
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/swaggo/swag/gen"
+)
+
+func TestSwagger_Gen(t *testing.T) {
+	httpFolder := t.TempDir()
+	main := `package http
+
+// @title Test API
+// @version 1.0
+// @BasePath /
+`
+	api := `package http
+
+// UserLogin 用户登录
+// @Summary 用户登录
+// @Produce json
+// @Param name query string true "用户名"
+// @Success 200 {string} string "ok"
+// @Router /user/login [get]
+func UserLogin() {}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(httpFolder, "swagger.go"), []byte(main), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(httpFolder, "api.go"), []byte(api), 0644))
+
+	conf := swaggerGenConfig(httpFolder)
+	assert.Equal(t, filepath.Join(httpFolder, "swagger"), conf.OutputDir)
+	require.NoError(t, gen.New().Build(conf))
+
+	for _, file := range []string{"docs.go", "swagger.json", "swagger.yaml"} {
+		assert.FileExists(t, filepath.Join(httpFolder, "swagger", file))
+	}
+	doc, err := os.ReadFile(filepath.Join(httpFolder, "swagger", "swagger.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), `"title": "Test API"`)
+	assert.Contains(t, string(doc), `"/user/login"`)
+}
+
+func TestSwagger_ServeAddr(t *testing.T) {
+	addr, err := swaggerServeAddr("http://127.0.0.1:8069")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8069", addr)
+	addr, err = swaggerServeAddr("http://:8069/")
+	require.NoError(t, err)
+	assert.Equal(t, ":8069", addr)
+
+	_, err = swaggerServeAddr("http://127.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestSwagger_Serve(t *testing.T) {
+	// 端口被占用的时候返回错误, 不退出进程
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	server := &http.Server{Addr: ln.Addr().String(), Handler: http.NotFoundHandler()}
+	assert.Error(t, swaggerServe(server, make(chan os.Signal)))
+
+	// 收到退出信号之后优雅关闭
+	quit := make(chan os.Signal, 1)
+	quit <- syscall.SIGTERM
+	server = &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	assert.NoError(t, swaggerServe(server, quit))
+}
+
+func TestSwagger_ServeEngine(t *testing.T) {
+	docFile := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(docFile, []byte(`{"swagger":"2.0"}`), 0644))
+	engine := swaggerServeEngine(docFile)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/swagger/index.html", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"swagger":"2.0"}`, w.Body.String())
+}
@@ -1,9 +1,14 @@
 package framework
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Container 是一个服务容器，提供服务和获取服务的功能
@@ -24,6 +29,31 @@ type Container interface {
 	// 它是根据服务提供者注册的启动函数和传递的params参数实例化出来的
 	// 这个函数在需要为不同参数启动不同实例的时候非常有用
 	MakeNew(key string, params []interface{}) (interface{}, error)
+
+	// Shutdown 关闭容器, 按照依赖关系的相反顺序调用已经实例化的服务的Shutdown方法
+	// 依赖其他服务的服务先关闭, ctx结束的时候不再等待剩余的服务
+	Shutdown(ctx context.Context) error
+}
+
+// CycleError 服务之间存在循环依赖的时候返回的错误, Chain为依赖链, 首尾是同一个服务
+type CycleError struct {
+	Chain []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle detected: " + strings.Join(e.Chain, " -> ")
+}
+
+// ProviderInfo 服务提供者在容器中的状态, 用于查看容器内部的依赖关系
+type ProviderInfo struct {
+	// Name 服务提供者的凭证
+	Name string
+	// IsDefer 是否延迟实例化
+	IsDefer bool
+	// Instantiated 是否已经实例化
+	Instantiated bool
+	// Dependencies 实例化的时候依赖的服务凭证
+	Dependencies []string
 }
 
 // MainContainer 服务容器的具体实现
@@ -33,141 +63,367 @@ type ServiceContainer struct {
 	providers map[string]ServiceProvider
 	// instance 存储具体的实例 ,key为凭证
 	instances map[string]interface{}
-	// lock 用于锁住对容器的变更操作 读写锁
+	// dependencies 存储实例化过程中记录的依赖关系, key为凭证, value为它依赖的服务凭证
+	dependencies map[string]map[string]struct{}
+	// order 记录服务实例化的顺序
+	order []string
+	// lock 用于锁住对容器的变更操作 读写锁, 调用服务提供者的方法的时候不持有锁
 	lock sync.RWMutex
 }
 
 //创建一个服务容器
 func NewContainer() *ServiceContainer {
 	return &ServiceContainer{
-		providers: map[string]ServiceProvider{},
-		instances: map[string]interface{}{},
-		lock: sync.RWMutex{},
+		providers:    map[string]ServiceProvider{},
+		instances:    map[string]interface{}{},
+		dependencies: map[string]map[string]struct{}{},
+		lock:         sync.RWMutex{},
 	}
 }
 
 //PrintProviders 输出服务容器中注册的关键字
 func (sc *ServiceContainer) PrintProviders() []string {
-	ret := []string{}
-	for _,provider := range sc.providers { 
-		name := provider.Name()
-		line := fmt.Sprint(name)
-		ret = append(ret, line)
-	}
-	return ret
+	return sc.NameList()
 }
 
 // Bind 将服务容器和关键字做了绑定
-func (sc *ServiceContainer) Bind(prodiver ServiceProvider) error {
-	sc.lock.Lock()
-	key := prodiver.Name()
-	sc.providers[key] = prodiver
+func (sc *ServiceContainer) Bind(provider ServiceProvider) error {
+	key := provider.Name()
 
+	sc.lock.Lock()
+	sc.providers[key] = provider
+	// 替换了服务提供者，之前的实例和依赖关系都已经失效
+	delete(sc.instances, key)
+	delete(sc.dependencies, key)
+	sc.order = removeKey(sc.order, key)
 	sc.lock.Unlock()
-	// if provider is not defer
-	if !prodiver.IsDefer()  {
-		if err := prodiver.Boot(sc);err != nil {
-			return err
-		}
-		//实例化
-		params := prodiver.Params(sc)
-		method := prodiver.Register(sc)
-		instance, err := method(params...)
-		if err != nil {
+
+	// if provider is not defer, 作用域服务只在作用域中实例化
+	if !provider.IsDefer() && !isScoped(provider) {
+		if _, err := sc.make(key, nil, false, nil); err != nil {
 			fmt.Println("bind service provider ", key, " error: ", err)
-			return errors.New(err.Error())
+			return err
 		}
-
-		sc.instances[key] = instance
 	}
-
 	return nil
 }
 
 func (sc *ServiceContainer) IsBind(key string) bool {
 	return sc.findServiceProvider(key) != nil
 }
+
 func (sc *ServiceContainer) findServiceProvider(key string) ServiceProvider {
 	sc.lock.RLock()
-	defer sc.lock.RUnlock()	
-	if sp,ok := sc.providers[key];ok {
+	defer sc.lock.RUnlock()
+	if sp, ok := sc.providers[key]; ok {
 		return sp
 	}
 	return nil
 }
 
 func (sc *ServiceContainer) Make(key string) (interface{}, error) {
-	return sc.make(key,nil,false)
+	return sc.make(key, nil, false, nil)
 }
 
-func (sc *ServiceContainer) MustMake(key string) (interface{})  {
-	sp,err := sc.make(key,nil,true)
+func (sc *ServiceContainer) MustMake(key string) interface{} {
+	sp, err := sc.make(key, nil, false, nil)
 	if err != nil {
 		panic(err)
 	}
-	
-
 	return sp
 }
 
-func (sc *ServiceContainer) MakeNew(key string, params[]interface{}) (interface{}, error) {
-	return sc.make(key, params, true)
+func (sc *ServiceContainer) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return sc.make(key, params, true, nil)
+}
+
+// newInstance 实例化一个服务, 传递给服务提供者的容器会记录这次实例化的调用链
+func (sc *ServiceContainer) newInstance(sp ServiceProvider, params []interface{}, chain []string) (interface{}, error) {
+	r := &resolver{ServiceContainer: sc, chain: chain}
+	return newInstanceWith(sp, params, r, r.finish)
 }
 
-func (sc *ServiceContainer) newInstance(sp ServiceProvider, params []interface{}) (interface{}, error) {
-	
-	//force a new 
-	if err := sp.Boot(sc);err != nil {
-		return nil,err
+// newInstanceWith 使用容器c实例化一个服务, 结束的时候调用finish
+// 实例化过程中, 服务提供者使用MustMake遇到循环依赖产生的panic, 会被转换为error返回
+func newInstanceWith(sp ServiceProvider, params []interface{}, c Container, finish func()) (ins interface{}, err error) {
+	defer finish()
+	defer func() {
+		if e := recover(); e != nil {
+			cycleErr, ok := e.(*CycleError)
+			if !ok {
+				panic(e)
+			}
+			ins, err = nil, cycleErr
+		}
+	}()
+
+	//force a new
+	if err := sp.Boot(c); err != nil {
+		return nil, err
 	}
 	if params == nil {
-		params = sp.Params(sc)
+		params = sp.Params(c)
 	}
-	method := sp.Register(sc)
-	ins, err := method(params...)
+	method := sp.Register(c)
+	ins, err = method(params...)
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
-	return ins,nil
+	return ins, nil
 }
 
+// addDependency 记录调用链中最后一个服务依赖了key对应的服务
+func (sc *ServiceContainer) addDependency(chain []string, key string) {
+	if len(chain) == 0 {
+		return
+	}
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	from := chain[len(chain)-1]
+	if sc.dependencies[from] == nil {
+		sc.dependencies[from] = map[string]struct{}{}
+	}
+	sc.dependencies[from][key] = struct{}{}
+}
 
-func (sc *ServiceContainer) make(key string,params []interface{}, forceNew bool) (interface{}, error) {
-	sc.lock.RLock()
-	defer sc.lock.RUnlock()
+// nextChain 将key加入调用链, 如果key已经在调用链中，说明存在循环依赖
+func nextChain(chain []string, key string) ([]string, error) {
+	for i, name := range chain {
+		if name == key {
+			cycle := append(append([]string{}, chain[i:]...), key)
+			return nil, &CycleError{Chain: cycle}
+		}
+	}
+	return append(append(make([]string, 0, len(chain)+1), chain...), key), nil
+}
+
+// make 获取一个服务, chain为当前正在实例化的服务调用链, 用于记录依赖关系和检测循环依赖
+func (sc *ServiceContainer) make(key string, params []interface{}, forceNew bool, chain []string) (interface{}, error) {
 	// 查询是否已经注册了这个服务提供者，如果没有注册，则返回错误
 	sp := sc.findServiceProvider(key)
-
 	if sp == nil {
-		return nil,errors.New("contract " + key + " have not register")
+		return nil, errors.New("contract " + key + " have not register")
+	}
+	if isScoped(sp) && !forceNew {
+		return nil, errors.New("contract " + key + " is scoped, please make it in a scope")
 	}
+	sc.addDependency(chain, key)
 
-	
 	// 不需要强制重新实例化，如果容器中已经实例化了，那么就直接使用容器中的实例
-	
-	if ins, ok := sc.instances[key];ok {
-		return ins,nil
+	if !forceNew {
+		sc.lock.RLock()
+		ins, ok := sc.instances[key]
+		sc.lock.RUnlock()
+		if ok {
+			return ins, nil
+		}
 	}
 
+	next, err := nextChain(chain, key)
+	if err != nil {
+		return nil, err
+	}
 	if forceNew {
-		return sc.newInstance(sp,params)
+		return sc.newInstance(sp, params, next)
 	}
 	// 容器中还未实例化，则进行一次实例化
-	insn,err := sc.newInstance(sp,params)
+	ins, err := sc.newInstance(sp, params, next)
 	if err != nil {
 		return nil, err
 	}
-	sc.instances[key] = insn
-	return insn,nil
-}
 
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	// 并发实例化的时候，以先存入容器的实例为准
+	if exist, ok := sc.instances[key]; ok {
+		return exist, nil
+	}
+	sc.instances[key] = ins
+	sc.order = append(sc.order, key)
+	return ins, nil
+}
 
 // NameList 列出容器中所有服务提供者的字符串凭证
-func (c *ServiceContainer) NameList() []string {
+func (sc *ServiceContainer) NameList() []string {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
 	ret := []string{}
-	for _, provider := range c.providers {
+	for _, provider := range sc.providers {
 		name := provider.Name()
 		ret = append(ret, name)
 	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ProviderInfos 列出容器中所有服务提供者的状态和依赖关系, 按照凭证排序
+// 依赖关系是在实例化的过程中记录的, 还未实例化的服务没有依赖信息
+func (sc *ServiceContainer) ProviderInfos() []ProviderInfo {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	ret := make([]ProviderInfo, 0, len(sc.providers))
+	for key, provider := range sc.providers {
+		_, instantiated := sc.instances[key]
+		deps := make([]string, 0, len(sc.dependencies[key]))
+		for dep := range sc.dependencies[key] {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		ret = append(ret, ProviderInfo{
+			Name:         key,
+			IsDefer:      provider.IsDefer(),
+			Instantiated: instantiated,
+			Dependencies: deps,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
 	return ret
-}
\ No newline at end of file
+}
+
+// Shutdown 关闭容器中已经实例化的服务, 服务实例和服务提供者实现了Shutdowner的时候会被调用
+// 依赖其他服务的服务先关闭, 没有依赖关系的服务按照实例化的相反顺序关闭
+// 关闭之后服务实例会从容器中移除, 再次获取的时候会重新实例化
+func (sc *ServiceContainer) Shutdown(ctx context.Context) error {
+	sc.lock.Lock()
+	keys := sc.shutdownOrder()
+	instances := make([]interface{}, len(keys))
+	providers := make([]ServiceProvider, len(keys))
+	for i, key := range keys {
+		instances[i] = sc.instances[key]
+		providers[i] = sc.providers[key]
+		delete(sc.instances, key)
+	}
+	sc.order = nil
+	sc.lock.Unlock()
+
+	errs := []string{}
+	for i, key := range keys {
+		if ctx.Err() != nil {
+			errs = append(errs, "shutdown "+strings.Join(keys[i:], ", ")+" skipped: "+ctx.Err().Error())
+			break
+		}
+		hooks := []Shutdowner{}
+		if s, ok := instances[i].(Shutdowner); ok {
+			hooks = append(hooks, s)
+		}
+		if s, ok := providers[i].(Shutdowner); ok && !sameValue(providers[i], instances[i]) {
+			hooks = append(hooks, s)
+		}
+		for _, hook := range hooks {
+			if err := callShutdown(ctx, hook); err != nil {
+				errs = append(errs, "shutdown "+key+" error: "+err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// shutdownOrder 计算服务关闭的顺序, 调用的时候需要持有锁
+// 一个服务只有在所有依赖它的服务都关闭之后才关闭
+func (sc *ServiceContainer) shutdownOrder() []string {
+	dependents := map[string][]string{}
+	for i := len(sc.order) - 1; i >= 0; i-- {
+		key := sc.order[i]
+		for dep := range sc.dependencies[key] {
+			dependents[dep] = append(dependents[dep], key)
+		}
+	}
+
+	ret := make([]string, 0, len(sc.order))
+	visited := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		for _, dependent := range dependents[key] {
+			if _, ok := sc.instances[dependent]; ok {
+				visit(dependent)
+			}
+		}
+		ret = append(ret, key)
+	}
+	for i := len(sc.order) - 1; i >= 0; i-- {
+		visit(sc.order[i])
+	}
+	return ret
+}
+
+// callShutdown 调用Shutdown方法, ctx结束的时候不再等待Shutdown返回
+func callShutdown(ctx context.Context, s Shutdowner) (err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				done <- fmt.Errorf("panic: %v", e)
+			}
+		}()
+		done <- s.Shutdown(ctx)
+	}()
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sameValue 判断服务提供者是否把自己作为服务实例, 类型不可比较的时候认为不相同
+func sameValue(a, b interface{}) bool {
+	t := reflect.TypeOf(a)
+	return t != nil && t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+// removeKey 从keys中移除key
+func removeKey(keys []string, key string) []string {
+	ret := keys[:0]
+	for _, k := range keys {
+		if k != key {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
+
+// resolver 实例化服务的时候传递给服务提供者的容器, 记录了当前实例化的调用链
+// 实例化结束之后, 服务中保存的resolver和ServiceContainer的行为一致, 不再记录依赖关系
+type resolver struct {
+	*ServiceContainer
+	chain []string
+	done  int32
+}
+
+// finish 标记实例化结束
+func (r *resolver) finish() {
+	atomic.StoreInt32(&r.done, 1)
+}
+
+// currentChain 获取当前的调用链, 实例化结束之后返回nil
+func (r *resolver) currentChain() []string {
+	if atomic.LoadInt32(&r.done) == 1 {
+		return nil
+	}
+	return r.chain
+}
+
+func (r *resolver) Make(key string) (interface{}, error) {
+	return r.ServiceContainer.make(key, nil, false, r.currentChain())
+}
+
+func (r *resolver) MustMake(key string) interface{} {
+	ins, err := r.ServiceContainer.make(key, nil, false, r.currentChain())
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+func (r *resolver) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return r.ServiceContainer.make(key, params, true, r.currentChain())
+}
@@ -0,0 +1,176 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider 测试使用的服务提供者, 实例化的时候依次获取deps中的服务
+type testProvider struct {
+	name    string
+	deps    []string
+	isDefer bool
+	count   int
+	lock    sync.Mutex
+}
+
+func (p *testProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		p.lock.Lock()
+		p.count++
+		p.lock.Unlock()
+		return &struct{ name string }{name: p.name}, nil
+	}
+}
+
+func (p *testProvider) Boot(c Container) error {
+	for _, dep := range p.deps {
+		c.MustMake(dep)
+	}
+	return nil
+}
+
+func (p *testProvider) IsDefer() bool { return p.isDefer }
+
+func (p *testProvider) Params(c Container) []interface{} { return []interface{}{c} }
+
+func (p *testProvider) Name() string { return p.name }
+
+func TestServiceContainer_Dependencies(t *testing.T) {
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testProvider{name: "a", isDefer: false}))
+	require.NoError(t, container.Bind(&testProvider{name: "b", deps: []string{"a"}, isDefer: true}))
+	require.NoError(t, container.Bind(&testProvider{name: "c", deps: []string{"a", "b"}, isDefer: true}))
+
+	_, err := container.Make("c")
+	require.NoError(t, err)
+
+	infos := container.ProviderInfos()
+	require.Len(t, infos, 3)
+	assert.Equal(t, ProviderInfo{Name: "a", IsDefer: false, Instantiated: true, Dependencies: []string{}}, infos[0])
+	assert.Equal(t, ProviderInfo{Name: "b", IsDefer: true, Instantiated: true, Dependencies: []string{"a"}}, infos[1])
+	assert.Equal(t, ProviderInfo{Name: "c", IsDefer: true, Instantiated: true, Dependencies: []string{"a", "b"}}, infos[2])
+}
+
+func TestServiceContainer_Cycle(t *testing.T) {
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testProvider{name: "a", deps: []string{"b"}, isDefer: true}))
+	require.NoError(t, container.Bind(&testProvider{name: "b", deps: []string{"c"}, isDefer: true}))
+	require.NoError(t, container.Bind(&testProvider{name: "c", deps: []string{"a"}, isDefer: true}))
+
+	_, err := container.Make("a")
+	var cycleErr *CycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, []string{"a", "b", "c", "a"}, cycleErr.Chain)
+	assert.EqualError(t, err, "dependency cycle detected: a -> b -> c -> a")
+
+	// 非延迟加载的服务在Bind的时候返回错误
+	err = container.Bind(&testProvider{name: "d", deps: []string{"d"}, isDefer: false})
+	assert.EqualError(t, err, "dependency cycle detected: d -> d")
+}
+
+func TestServiceContainer_MakeNew(t *testing.T) {
+	container := NewContainer()
+	provider := &testProvider{name: "a", isDefer: true}
+	require.NoError(t, container.Bind(provider))
+
+	ins1, err := container.Make("a")
+	require.NoError(t, err)
+	ins2, err := container.MakeNew("a", nil)
+	require.NoError(t, err)
+	assert.NotSame(t, ins1, ins2)
+	assert.Same(t, ins1, container.MustMake("a"))
+	assert.Equal(t, 2, provider.count)
+}
+
+func TestServiceContainer_Concurrent(t *testing.T) {
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testProvider{name: "a", isDefer: true}))
+	require.NoError(t, container.Bind(&testProvider{name: "b", deps: []string{"a"}, isDefer: true}))
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = container.MustMake("b")
+		}(i)
+	}
+	wg.Wait()
+	for _, ins := range results {
+		assert.Same(t, results[0], ins)
+	}
+}
+
+// testShutdownProvider 测试使用的服务提供者, 实例实现了Shutdowner, 关闭的时候记录名称
+type testShutdownProvider struct {
+	testProvider
+	shutdown *[]string
+	block    bool
+}
+
+func (p *testShutdownProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return &testShutdowner{name: p.name, shutdown: p.shutdown, block: p.block}, nil
+	}
+}
+
+type testShutdowner struct {
+	name     string
+	shutdown *[]string
+	block    bool
+}
+
+func (s *testShutdowner) Shutdown(ctx context.Context) error {
+	if s.block {
+		<-make(chan struct{})
+	}
+	*s.shutdown = append(*s.shutdown, s.name)
+	if s.name == "fail" {
+		return errors.New("fail")
+	}
+	return nil
+}
+
+func TestServiceContainer_Shutdown(t *testing.T) {
+	shutdown := []string{}
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "log", isDefer: true}, shutdown: &shutdown}))
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "config", isDefer: false}, shutdown: &shutdown}))
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "db", deps: []string{"config", "log"}, isDefer: true}, shutdown: &shutdown}))
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "unused", isDefer: true}, shutdown: &shutdown}))
+	// 先实例化log, 再实例化依赖log的db, 最后实例化和其他服务无关的cache
+	container.MustMake("log")
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "cache", isDefer: true}, shutdown: &shutdown}))
+	container.MustMake("db")
+	container.MustMake("cache")
+
+	require.NoError(t, container.Shutdown(context.Background()))
+	assert.Equal(t, []string{"cache", "db", "log", "config"}, shutdown)
+
+	// 关闭之后实例被移除, 再次关闭不会重复调用
+	require.NoError(t, container.Shutdown(context.Background()))
+	assert.Len(t, shutdown, 4)
+	assert.False(t, container.ProviderInfos()[0].Instantiated)
+}
+
+func TestServiceContainer_ShutdownDeadline(t *testing.T) {
+	shutdown := []string{}
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "a"}, shutdown: &shutdown}))
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "fail"}, shutdown: &shutdown}))
+	require.NoError(t, container.Bind(&testShutdownProvider{testProvider: testProvider{name: "block"}, shutdown: &shutdown, block: true}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := container.Shutdown(ctx)
+	assert.EqualError(t, err, "shutdown block error: context deadline exceeded; shutdown fail, a skipped: context deadline exceeded")
+	assert.Empty(t, shutdown)
+}
@@ -13,6 +13,8 @@ type App interface {
 	ConfigFolder() string
 	// LogFolder 定义了日志所在路径
 	LogFolder() string
+	// HttpFolder 定义业务的http服务代码所在的目录
+	HttpFolder() string
 	// ProviderFolder 定义业务自己的服务提供者地址
 	ProviderFolder() string
 	// MiddlewareFolder 定义业务自己定义的中间件
@@ -23,6 +25,8 @@ type App interface {
 	RuntimeFolder() string
 	// TestFolder 存放测试所需要的信息
 	TestFolder() string
+	// DeployFolder 存放部署的时候编译生成的文件
+	DeployFolder() string
 	// AppID 表示当前这个app的唯一id, 可以用于分布式锁等
 	AppID() string
 	// LoadAppConfig 加载新的AppConfig，key为对应的函数转为小写下划线，比如ConfigFolder => config_folder
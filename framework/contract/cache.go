@@ -0,0 +1,63 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"time"
+)
+
+// CacheKey 缓存服务的字符串凭证
+const CacheKey = "goweb:cache"
+
+var (
+	// ErrKeyNotFound 表示缓存中没有这个key
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrTypeNotOk 表示缓存中的值类型不符合要求, 比如对非数字的值做Incr操作
+	ErrTypeNotOk = errors.New("val type not ok")
+)
+
+// RememberFunc 缓存的Remember方法使用，Cache未命中的时候调用这个方法获取数据
+type RememberFunc func(ctx context.Context, container framework.Container) (interface{}, error)
+
+// CacheService 缓存服务, 所有的值以string存储, 对象使用json序列化存储
+// 业务中可以通过 framework.MustMake[contract.CacheService](c) 获取
+type CacheService interface {
+	// Get 获取某个key对应的值, key不存在的时候返回ErrKeyNotFound
+	Get(ctx context.Context, key string) (string, error)
+	// GetObj 获取某个key对应的对象, model为对象指针
+	GetObj(ctx context.Context, key string, model interface{}) error
+	// GetMany 获取多个key对应的值, 不存在的key不会出现在返回结果中
+	GetMany(ctx context.Context, keys []string) (map[string]string, error)
+
+	// Set 设置某个key和值, timeout为过期时间
+	Set(ctx context.Context, key string, val string, timeout time.Duration) error
+	// SetObj 设置某个key和对象, timeout为过期时间
+	SetObj(ctx context.Context, key string, val interface{}, timeout time.Duration) error
+	// SetMany 设置多个key和值, timeout为过期时间
+	SetMany(ctx context.Context, data map[string]string, timeout time.Duration) error
+	// SetForever 设置某个key和值，永不过期
+	SetForever(ctx context.Context, key string, val string) error
+	// SetForeverObj 设置某个key和对象，永不过期
+	SetForeverObj(ctx context.Context, key string, val interface{}) error
+
+	// SetTTL 设置某个key的过期时间
+	SetTTL(ctx context.Context, key string, timeout time.Duration) error
+	// GetTTL 获取某个key的剩余过期时间, 永不过期的key返回-1
+	GetTTL(ctx context.Context, key string) (time.Duration, error)
+
+	// Remember 实现缓存的Cache-Aside模式, 先去缓存中根据key获取对象，如果有的话，返回，如果没有，调用RememberFunc 生成
+	Remember(ctx context.Context, key string, timeout time.Duration, rememberFunc RememberFunc, model interface{}) error
+
+	// Calc 往key对应的值中增加step计数, key不存在的时候从0开始计算
+	Calc(ctx context.Context, key string, step int64) (int64, error)
+	// Incr 往key对应的值中增加1
+	Incr(ctx context.Context, key string) (int64, error)
+	// Decr 往key对应的值中减去1
+	Decr(ctx context.Context, key string) (int64, error)
+
+	// Delete 删除某个key
+	Delete(ctx context.Context, key string) error
+	// DeleteMany 删除多个key
+	DeleteMany(ctx context.Context, keys []string) error
+}
@@ -7,6 +7,47 @@ const (
 	ConfigKey = "goweb:config"
 )
 
+const (
+	// ConfigLayerDefault 服务提供者通过SetDefault设置的默认值, 优先级最低
+	ConfigLayerDefault = "default"
+	// ConfigLayerBase 配置文件根目录config/下的配置文件
+	ConfigLayerBase = "base"
+	// ConfigLayerEnv 环境目录config/{env}/下的配置文件
+	ConfigLayerEnv = "env"
+	// ConfigLayerRemote 配置文件remote.sources中定义的远程配置源, 比如http接口
+	ConfigLayerRemote = "remote"
+	// ConfigLayerLocal 本地目录config/{env}.local/下的配置文件, 不提交到代码库, 优先级最高
+	ConfigLayerLocal = "local"
+)
+
+// ConfigSource 某个配置项在一层配置中的值
+type ConfigSource struct {
+	// Layer 配置层的名称
+	Layer string
+	// File 配置文件的路径, 默认值没有文件
+	File string
+	// Value 配置项在这一层中的值
+	Value interface{}
+}
+
+// ConfigViolation 配置项不符合注册的结构
+type ConfigViolation struct {
+	// Key 配置项的路径, 例如: log.driver
+	Key string
+	// File 配置项所在的配置文件, 配置项不存在的时候为空
+	File string
+	// Message 不符合的原因
+	Message string
+}
+
+// String 打印为 file: key message 的格式
+func (v ConfigViolation) String() string {
+	if v.File == "" {
+		return v.Key + " " + v.Message
+	}
+	return v.File + ": " + v.Key + " " + v.Message
+}
+
 // Config 定义了配置文件服务，读取配置文件，支持点分割的路径读取
 // 例如: .Get("app.name") 表示从 app 文件中读取 name 属性
 // 建议使用 yaml 属性, https://yaml.org/spec/1.2/spec.html
@@ -37,4 +78,17 @@ type Config interface {
 	GetStringMapStringSlice(key string) map[string][]string
 	// Load 加载配置到某个对象
 	Load(key string, val interface{}) error
+	// Watch 监听某个属性的变化, 配置文件重新加载后这个属性的值发生了变化的时候调用fn
+	// old和new为变化前后的值, 属性不存在的时候为nil, 多次变化按照顺序依次通知, fn中不能调用Set
+	Watch(key string, fn func(old, new interface{}))
+	// Set 修改某个属性的值, 并且写回到属性所在的yaml配置文件, 保留文件中的注释和顺序
+	Set(key string, val interface{}) error
+	// SetDefault 设置某个属性的默认值, 配置文件中没有这个属性的时候使用
+	SetDefault(key string, val interface{})
+	// Explain 查找某个属性在每一层配置中的值, 按照优先级从高到低排列, 第一个为生效的值
+	Explain(key string) []ConfigSource
+	// IsSecret 判断某个属性的值是否由配置文件中的加密值enc(...)解密得到, 打印配置的时候需要隐藏
+	IsSecret(key string) bool
+	// Validate 按照服务提供者注册的结构校验配置, 返回所有不符合的配置项
+	Validate() []ConfigViolation
  }
\ No newline at end of file
@@ -1,5 +1,7 @@
 package contract
 
+import "time"
+
 const (
 	// EnvProduction 代表生产环境
 	EnvProduction = "production"
@@ -19,6 +21,20 @@ type Env interface {
 	IsExist(key string) bool
 	// Get 获取某个环境变量，如果没有设置，返回""
 	Get(key string) string
-	// All 获取所有的环境变量，.env 和运行环境变量融合后结果
+	// GetInt 获取一个 int 环境变量，按照十进制解析，没有设置或者格式错误返回0
+	GetInt(key string) int
+	// GetBool 获取一个 bool 环境变量
+	GetBool(key string) bool
+	// GetDuration 获取一个时间间隔环境变量，例如: 10s
+	GetDuration(key string) time.Duration
+	// GetStringSlice 获取一个逗号分割的环境变量
+	GetStringSlice(key string) []string
+	// Bind 按照字段的 env 标签，将 prefix+标签名 的环境变量设置到结构体指针中
+	Bind(prefix string, target interface{}) error
+	// Set 设置一个环境变量，一般用于测试
+	Set(key string, val string)
+	// Unset 删除一个环境变量，一般用于测试
+	Unset(key string)
+	// All 获取所有的环境变量，.env 和运行环境变量融合后结果，返回副本
 	All() map[string]string
 }
\ No newline at end of file
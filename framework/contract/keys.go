@@ -0,0 +1,19 @@
+package contract
+
+import "goweb/framework"
+
+// 注册服务接口对应的字符串凭证, 这样就可以通过 framework.Make[contract.Config](c) 获取服务
+func init() {
+	framework.RegisterKey[App](AppKey)
+	framework.RegisterKey[CacheService](CacheKey)
+	framework.RegisterKey[Config](ConfigKey)
+	framework.RegisterKey[Distributed](DistributedKey)
+	framework.RegisterKey[Env](EnvKey)
+	framework.RegisterKey[IDService](IDKey)
+	framework.RegisterKey[Kernel](KernelKey)
+	framework.RegisterKey[Log](LogKey)
+	framework.RegisterKey[ORMService](ORMKey)
+	framework.RegisterKey[RedisService](RedisKey)
+	framework.RegisterKey[SSHService](SSHKey)
+	framework.RegisterKey[Trace](TraceKey)
+}
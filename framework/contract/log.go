@@ -31,6 +31,15 @@ const (
 type CtxFielder func(ctx context.Context) map[string]interface{}
 // Formatter 定义了将日志信息组织成字符串的通用方法
 type Formatter func(level LogLevel, t time.Time, msg string, fields map[string]interface{}) ([]byte, error)
+// LogStats 日志服务的统计信息, 用于监控日志是否有丢失
+type LogStats struct {
+	// Buffered 异步模式下缓冲区中还没有写入的日志条数
+	Buffered int
+	// Dropped 异步模式下缓冲区满的时候丢弃的日志条数
+	Dropped uint64
+	// WriteErrors 写入输出失败的次数
+	WriteErrors uint64
+}
 type Log interface {
 	// Panic 表示会导致整个程序出现崩溃的日志信息
 	Panic(ctx context.Context, msg string, fields map[string]interface{})
@@ -54,4 +63,10 @@ type Log interface {
 	SetFormatter(formatter Formatter)
 	// SetOutput 设置输出管道
 	SetOutput(out io.Writer)
+	// Stats 获取缓冲, 丢弃和写入失败的日志条数
+	Stats() LogStats
+	// With 返回带有固定字段的日志, 每条日志都会带上这些字段, 不修改当前日志
+	With(fields map[string]interface{}) Log
+	// Named 返回指定模块名的日志, 模块名输出在 module 字段中, 多次调用使用.连接
+	Named(module string) Log
 }
\ No newline at end of file
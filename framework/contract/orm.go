@@ -0,0 +1,102 @@
+package contract
+
+import (
+	"context"
+	"goweb/framework"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ORMKey 代表 ORM的服务
+const ORMKey = "goweb:orm"
+
+// ORMService 表示ORM服务，根据database.yaml中的连接配置获取*gorm.DB
+type ORMService interface {
+	// GetDB 获取某个连接的DB实例，同一个连接只会创建一次连接池
+	GetDB(option ...DBOption) (*gorm.DB, error)
+	// CanConnect 判断某个DB实例是否可以正常连接
+	CanConnect(ctx context.Context, db *gorm.DB) (bool, error)
+}
+
+// DBOption 代表初始化的时候的选项
+type DBOption func(container framework.Container, config *DBConfig) error
+
+// DBConfig 代表数据库连接的所有配置
+type DBConfig struct {
+	// 以下配置关于dsn
+	Driver       string `yaml:"driver"`        // 连接的驱动, 支持mysql, sqlite
+	Dsn          string `yaml:"dsn"`           // dsn，如果设置了dsn, 以下的所有设置都不生效
+	Host         string `yaml:"host"`          // 数据库地址
+	Port         int    `yaml:"port"`          // 端口
+	Database     string `yaml:"database"`      // 数据库
+	Username     string `yaml:"username"`      // 用户名
+	Password     string `yaml:"password"`      // 密码
+	Protocol     string `yaml:"protocol"`      // 传输协议
+	Charset      string `yaml:"charset"`       // 字符集
+	Collation    string `yaml:"collation"`     // 字符序
+	Loc          string `yaml:"loc"`           // 时区
+	ParseTime    bool   `yaml:"parse_time"`    // 是否解析时间
+	Timeout      string `yaml:"timeout"`       // 连接超时
+	ReadTimeout  string `yaml:"read_timeout"`  // 读超时
+	WriteTimeout string `yaml:"write_timeout"` // 写超时
+
+	// 以下配置关于连接池
+	ConnMaxIdle     int    `yaml:"conn_max_idle"`     // 最大空闲连接数
+	ConnMaxOpen     int    `yaml:"conn_max_open"`     // 最大连接数
+	ConnMaxLifetime string `yaml:"conn_max_lifetime"` // 连接最大生命周期
+	ConnMaxIdletime string `yaml:"conn_max_idletime"` // 空闲最大生命周期
+
+	// 以下配置关于gorm
+	*gorm.Config // 集成gorm的配置
+}
+
+// FormatDsn 生成mysql的dsn
+func (conf *DBConfig) FormatDsn() (string, error) {
+	port := strconv.Itoa(conf.Port)
+	timeout, err := parseDuration(conf.Timeout)
+	if err != nil {
+		return "", err
+	}
+	readTimeout, err := parseDuration(conf.ReadTimeout)
+	if err != nil {
+		return "", err
+	}
+	writeTimeout, err := parseDuration(conf.WriteTimeout)
+	if err != nil {
+		return "", err
+	}
+	location, err := time.LoadLocation(conf.Loc)
+	if err != nil {
+		return "", err
+	}
+	driverConf := &mysql.Config{
+		User:                 conf.Username,
+		Passwd:               conf.Password,
+		Net:                  conf.Protocol,
+		Addr:                 net.JoinHostPort(conf.Host, port),
+		DBName:               conf.Database,
+		Collation:            conf.Collation,
+		Loc:                  location,
+		Timeout:              timeout,
+		ReadTimeout:          readTimeout,
+		WriteTimeout:         writeTimeout,
+		ParseTime:            conf.ParseTime,
+		AllowNativePasswords: true,
+	}
+	if conf.Charset != "" {
+		driverConf.Params = map[string]string{"charset": conf.Charset}
+	}
+	return driverConf.FormatDSN(), nil
+}
+
+// parseDuration 解析时长配置，未配置的时候返回0
+func parseDuration(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(val)
+}
@@ -0,0 +1,39 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"goweb/framework"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisKey redis服务的字符串凭证
+const RedisKey = "goweb:redis"
+
+// RedisOption 代表初始化的时候的选项
+type RedisOption func(container framework.Container, config *RedisConfig) error
+
+// RedisService 表示一个redis服务，根据redis.yaml中的连接配置获取*redis.Client
+type RedisService interface {
+	// GetClient 获取redis连接实例，相同配置的连接只会创建一次
+	GetClient(option ...RedisOption) (*redis.Client, error)
+}
+
+// RedisConfig 为框架定义的Redis配置结构
+type RedisConfig struct {
+	*redis.Options
+}
+
+// UniqKey 用来唯一标识一个RedisConfig配置
+// 包含所有影响连接的配置项, 密码等配置不同的连接不会复用同一个client, 使用hash避免密码明文出现在key中
+func (config *RedisConfig) UniqKey() string {
+	key := fmt.Sprintf("%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%v_%p_%p_%p_%p",
+		config.Network, config.Addr, config.DB, config.Username, config.Password,
+		config.MaxRetries, config.MinRetryBackoff, config.MaxRetryBackoff,
+		config.DialTimeout, config.ReadTimeout, config.WriteTimeout,
+		config.PoolFIFO, config.PoolSize, config.MinIdleConns, config.MaxConnAge,
+		config.PoolTimeout, config.IdleTimeout, config.IdleCheckFrequency,
+		config.TLSConfig, config.Dialer, config.OnConnect, config.Limiter)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
@@ -0,0 +1,38 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"goweb/framework"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKey 代表ssh服务的字符串凭证
+const SSHKey = "goweb:ssh"
+
+// SSHOption 代表初始化的时候的选项
+type SSHOption func(container framework.Container, config *SSHConfig) error
+
+// SSHService 表示一个ssh服务
+type SSHService interface {
+	// GetClient 获取ssh连接实例，相同配置的连接只会创建一次
+	GetClient(option ...SSHOption) (*ssh.Client, error)
+}
+
+// SSHConfig 为ssh连接的配置结构
+type SSHConfig struct {
+	NetWork string
+	Host    string
+	Port    string
+	// AuthKey 标识Auth和HostKeyCallback的内容, 比如密码, 私钥, known_hosts文件
+	// 函数类型的Auth和HostKeyCallback无法比较, 只有AuthKey相同的配置才会复用同一个连接
+	AuthKey string
+	*ssh.ClientConfig
+}
+
+// UniqKey 用来唯一标识一个SSHConfig配置, 使用hash避免密码明文出现在key中
+func (config *SSHConfig) UniqKey() string {
+	key := fmt.Sprintf("%v_%v_%v_%v_%v", config.NetWork, config.Host, config.Port, config.User, config.AuthKey)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
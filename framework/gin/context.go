@@ -89,6 +89,8 @@ type Context struct {
 
 	// service container
 	container framework.Container
+	// scope 请求的服务作用域, 由Scope中间件设置
+	scope *framework.Scope
 }
 
 /************************************/
@@ -110,6 +112,7 @@ func (c *Context) reset() {
 	c.sameSite = 0
 	*c.params = (*c.params)[:0]
 	*c.skippedNodes = (*c.skippedNodes)[:0]
+	c.scope = nil
 }
 
 // Copy returns a copy of the current context that can be safely used outside the request's scope.
@@ -120,6 +123,8 @@ func (c *Context) Copy() *Context {
 		Request:   c.Request,
 		Params:    c.Params,
 		engine:    c.engine,
+		// 请求结束的时候作用域会被关闭, 所以拷贝只保留容器, 不保留作用域
+		container: c.container,
 	}
 	cp.writermem.ResponseWriter = nil
 	cp.Writer = &cp.writermem
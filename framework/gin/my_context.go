@@ -1,17 +1,42 @@
 package gin
 
+import "goweb/framework"
+
 /// engine 实现容器 功能 end
 
 // context 实现 container 的几个封装
 
+// getContainer 获取当前请求使用的容器, 设置了作用域的时候使用作用域
+func (ctx *Context) getContainer() framework.Container {
+	if ctx.scope != nil {
+		return ctx.scope
+	}
+	return ctx.container
+}
+
+// Container 获取引擎设置的服务容器
+func (ctx *Context) Container() framework.Container {
+	return ctx.container
+}
+
+// SetScope 设置当前请求的服务作用域
+func (ctx *Context) SetScope(scope *framework.Scope) {
+	ctx.scope = scope
+}
+
+// Scope 获取当前请求的服务作用域, 没有设置的时候返回nil
+func (ctx *Context) Scope() *framework.Scope {
+	return ctx.scope
+}
+
 func (ctx *Context) Make(key string) (interface{},error) {
-	return ctx.container.Make(key)
+	return ctx.getContainer().Make(key)
 }
 
 func (ctx *Context) MustMake(key string) (interface{}) {
-	return ctx.container.MustMake(key)
+	return ctx.getContainer().MustMake(key)
 }
 
 func (ctx *Context) MakeNew(key string,params []interface{}) (interface{},error) {
-	return ctx.container.MakeNew(key,params)
-}
\ No newline at end of file
+	return ctx.getContainer().MakeNew(key,params)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"goweb/framework"
+	"goweb/framework/gin"
+	"log"
+)
+
+// Scope 为每个请求创建一个服务作用域, 请求中通过c.Make获取的作用域服务在请求内只实例化一次
+// 请求结束的时候关闭作用域, 作用域内实现了io.Closer的服务会按照实例化的相反顺序关闭
+func Scope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 已经设置了作用域的请求不重复创建
+		if c.Scope() != nil {
+			c.Next()
+			return
+		}
+		scope, err := framework.NewScope(c.Container())
+		if err != nil {
+			log.Println("create scope error:", err)
+			c.Next()
+			return
+		}
+		c.SetScope(scope)
+		defer func() {
+			if err := scope.Close(); err != nil {
+				log.Println("close scope error:", err)
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,157 @@
+package swagger
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"goweb/framework/gin"
+
+	"github.com/swaggo/swag"
+	"golang.org/x/net/webdav"
+)
+
+// Config swagger ui的配置
+type Config struct {
+	// URL 文档地址, 默认为同级目录下的doc.json
+	URL string
+	// DocExpansion 文档的展开方式, list/full/none
+	DocExpansion string
+	// DeepLinking 是否开启深度链接
+	DeepLinking bool
+	// InstanceName swag注册文档使用的名称, 默认为swagger
+	InstanceName string
+	// DocFile 文档文件路径, 设置后doc.json直接读取这个文件, 否则读取swag中注册的文档
+	DocFile string
+}
+
+// URL 设置文档地址
+func URL(url string) func(*Config) {
+	return func(c *Config) {
+		c.URL = url
+	}
+}
+
+// DocExpansion 设置文档的展开方式
+func DocExpansion(docExpansion string) func(*Config) {
+	return func(c *Config) {
+		c.DocExpansion = docExpansion
+	}
+}
+
+// InstanceName 设置swag注册文档使用的名称
+func InstanceName(name string) func(*Config) {
+	return func(c *Config) {
+		c.InstanceName = name
+	}
+}
+
+// DocFile 设置文档文件路径, 每次请求都会重新读取, 所以重新生成文档后不需要重启
+func DocFile(file string) func(*Config) {
+	return func(c *Config) {
+		c.DocFile = file
+	}
+}
+
+// WrapHandler 将swaggo/files的静态文件包装为gin的HandlerFunc, 路由需要使用通配符, 比如 /swagger/*any
+func WrapHandler(h *webdav.Handler, confs ...func(*Config)) gin.HandlerFunc {
+	config := &Config{
+		URL:          "doc.json",
+		DocExpansion: "list",
+		DeepLinking:  true,
+		InstanceName: swag.Name,
+	}
+	for _, c := range confs {
+		c(config)
+	}
+
+	index := template.Must(template.New("swagger_index.html").Parse(indexTemplate))
+	// 每个路由使用独立的handler, 防止修改Prefix的时候互相影响
+	handler := &webdav.Handler{FileSystem: h.FileSystem, LockSystem: h.LockSystem}
+	var once sync.Once
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+
+		file := strings.TrimPrefix(c.Param("any"), "/")
+		once.Do(func() {
+			handler.Prefix = strings.TrimSuffix(c.Request.URL.Path, file)
+		})
+
+		switch file {
+		case "", "index.html":
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			if err := index.Execute(c.Writer, config); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+			}
+		case "doc.json":
+			doc, err := readDoc(config)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.String(http.StatusOK, doc)
+		default:
+			handler.ServeHTTP(c.Writer, c.Request)
+		}
+	}
+}
+
+// readDoc 读取文档内容
+func readDoc(config *Config) (string, error) {
+	if config.DocFile != "" {
+		bt, err := os.ReadFile(config.DocFile)
+		if err != nil {
+			return "", err
+		}
+		return string(bt), nil
+	}
+	return swag.ReadDoc(config.InstanceName)
+}
+
+const indexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>Swagger UI</title>
+  <link rel="stylesheet" type="text/css" href="./swagger-ui.css" >
+  <link rel="icon" type="image/png" href="./favicon-32x32.png" sizes="32x32" />
+  <link rel="icon" type="image/png" href="./favicon-16x16.png" sizes="16x16" />
+  <style>
+    html { box-sizing: border-box; overflow-y: scroll; }
+    *, *:before, *:after { box-sizing: inherit; }
+    body { margin: 0; background: #fafafa; }
+  </style>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="./swagger-ui-bundle.js"></script>
+<script src="./swagger-ui-standalone-preset.js"></script>
+<script>
+window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "{{.URL}}",
+    dom_id: '#swagger-ui',
+    validatorUrl: null,
+    docExpansion: "{{.DocExpansion}}",
+    deepLinking: {{.DeepLinking}},
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  })
+}
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,70 @@
+package swagger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goweb/framework/gin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	swaggerFiles "github.com/swaggo/files"
+	"github.com/swaggo/swag"
+)
+
+type testDoc struct{}
+
+func (testDoc) ReadDoc() string {
+	return `{"info":{"title":"registered"}}`
+}
+
+func init() {
+	swag.Register("swagger_test", testDoc{})
+}
+
+func serve(engine *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestWrapHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	docFile := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(docFile, []byte(`{"info":{"title":"file"}}`), 0644))
+
+	engine := gin.New()
+	engine.GET("/swagger/*any", WrapHandler(swaggerFiles.Handler, InstanceName("swagger_test"), DocExpansion("none")))
+	engine.GET("/file/*any", WrapHandler(swaggerFiles.Handler, DocFile(docFile)))
+	engine.POST("/swagger/*any", WrapHandler(swaggerFiles.Handler))
+
+	w := serve(engine, http.MethodGet, "/swagger/index.html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `url: "doc.json"`)
+	assert.Contains(t, w.Body.String(), `docExpansion: "none"`)
+
+	// 文档读取swag中注册的文档
+	w = serve(engine, http.MethodGet, "/swagger/doc.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"info":{"title":"registered"}}`, w.Body.String())
+
+	// 设置DocFile之后读取文件, 每次请求重新读取
+	w = serve(engine, http.MethodGet, "/file/doc.json")
+	assert.JSONEq(t, `{"info":{"title":"file"}}`, w.Body.String())
+	require.NoError(t, os.WriteFile(docFile, []byte(`{"info":{"title":"changed"}}`), 0644))
+	w = serve(engine, http.MethodGet, "/file/doc.json")
+	assert.JSONEq(t, `{"info":{"title":"changed"}}`, w.Body.String())
+
+	// 静态文件由swaggo/files提供, 两个路由的前缀互不影响
+	w = serve(engine, http.MethodGet, "/swagger/swagger-ui.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(engine, http.MethodGet, "/file/swagger-ui.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(engine, http.MethodPost, "/swagger/index.html")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -1,5 +1,7 @@
 package framework
 
+import "context"
+
 // NewInstance 定义了如何创建一个新实例，所有服务容器的创建服务
 type NewInstance func(...interface{}) (interface{}, error)
 
@@ -17,4 +19,23 @@ type ServiceProvider interface {
 	Params(Container) []interface{}
 	// Name 代表了这个服务提供者的凭证
 	Name() string
-}
\ No newline at end of file
+}
+
+// ScopedProvider 服务提供者可以选择实现这个接口, IsScoped返回true的时候, 服务的生命周期为作用域
+// 在同一个作用域(比如一次http请求)内只实例化一次, 作用域结束的时候, 实现了io.Closer的实例会被关闭
+// 作用域的服务只能通过Scope获取, 不能直接从容器中获取
+type ScopedProvider interface {
+	IsScoped() bool
+}
+
+// isScoped 判断服务提供者是否是作用域服务
+func isScoped(sp ServiceProvider) bool {
+	scoped, ok := sp.(ScopedProvider)
+	return ok && scoped.IsScoped()
+}
+
+// Shutdowner 服务提供者或者服务实例可以选择实现这个接口, 在容器关闭的时候释放资源
+// 比如关闭文件, 停止监听的goroutine, 关闭连接池等, ctx结束的时候应该尽快返回
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
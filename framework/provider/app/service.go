@@ -57,6 +57,7 @@ func (app App) LogFolder() string {
 	return filepath.Join(app.StorageFolder(), "log")
 }
 
+// HttpFolder 定义业务的http服务代码所在的目录
 func (app App) HttpFolder() string {
 	if val, ok := app.configMap["http_folder"]; ok {
 		return val
@@ -118,7 +119,7 @@ func (app App) TestFolder() string {
 	return filepath.Join(app.BaseFolder(), "test")
 }
 
-// DeployFolder 定义测试需要的信息
+// DeployFolder 定义部署时编译生成文件的存放目录
 func (app App) DeployFolder() string {
 	if val, ok := app.configMap["deploy_folder"]; ok {
 		return val
@@ -0,0 +1,29 @@
+package cache
+
+import "goweb/framework/provider/config"
+
+// cacheSchema cache.yaml的配置结构, 启动和config validate的时候校验
+type cacheSchema struct {
+	Driver   string `yaml:"driver" validate:"omitempty,oneof=memory file redis"`
+	Capacity int    `yaml:"capacity" validate:"gte=0"`
+	Folder   string `yaml:"folder"`
+
+	// redis 驱动的配置
+	Connection      string `yaml:"connection"`
+	Host            string `yaml:"host"`
+	Port            int    `yaml:"port" validate:"omitempty,gt=0,lte=65535"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	DB              int    `yaml:"db" validate:"gte=0"`
+	Timeout         string `yaml:"timeout" validate:"duration"`
+	ReadTimeout     string `yaml:"read_timeout" validate:"duration"`
+	WriteTimeout    string `yaml:"write_timeout" validate:"duration"`
+	ConnMinIdle     int    `yaml:"conn_min_idle" validate:"gte=0"`
+	ConnMaxOpen     int    `yaml:"conn_max_open" validate:"gte=0"`
+	ConnMaxLifetime string `yaml:"conn_max_lifetime" validate:"duration"`
+	ConnMaxIdletime string `yaml:"conn_max_idletime" validate:"duration"`
+}
+
+func init() {
+	config.RegisterSchema("cache", cacheSchema{})
+}
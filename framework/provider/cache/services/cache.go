@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"time"
+)
+
+// setObj 将对象序列化为json之后使用set方法存储
+func setObj(ctx context.Context, set func(ctx context.Context, key string, val string, timeout time.Duration) error, key string, val interface{}, timeout time.Duration) error {
+	bt, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return set(ctx, key, string(bt), timeout)
+}
+
+// getObj 使用get方法获取json之后反序列化到model中
+func getObj(ctx context.Context, get func(ctx context.Context, key string) (string, error), key string, model interface{}) error {
+	val, err := get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(val), model)
+}
+
+// remember 实现各个驱动通用的Remember逻辑
+func remember(ctx context.Context, cache contract.CacheService, container framework.Container, key string, timeout time.Duration, rememberFunc contract.RememberFunc, model interface{}) error {
+	err := cache.GetObj(ctx, key, model)
+	// 如果返回为nil，说明有这个key，且有数据，obj已经注入了，返回nil
+	if err == nil {
+		return nil
+	}
+
+	// 有err，但是并不是key不存在，说明是有具体的error的，不能继续往下执行了，返回err
+	if !errors.Is(err, contract.ErrKeyNotFound) {
+		return err
+	}
+
+	// 以下是key不存在的情况，调用rememberFunc
+	objNew, err := rememberFunc(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	// 设置key
+	if err := cache.SetObj(ctx, key, objNew, timeout); err != nil {
+		return err
+	}
+	// 用GetObj获取这个key，注入model
+	return cache.GetObj(ctx, key, model)
+}
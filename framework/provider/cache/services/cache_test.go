@@ -0,0 +1,117 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheUser struct {
+	Name string
+	Age  int
+}
+
+// testCacheService 各个驱动通用的测试用例
+func testCacheService(t *testing.T, cache contract.CacheService) {
+	ctx := context.Background()
+
+	_, err := cache.Get(ctx, "not_exist")
+	assert.ErrorIs(t, err, contract.ErrKeyNotFound)
+
+	require.NoError(t, cache.Set(ctx, "foo", "bar", time.Hour))
+	val, err := cache.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", val)
+
+	ttl, err := cache.GetTTL(ctx, "foo")
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Hour)
+
+	require.NoError(t, cache.SetForeverObj(ctx, "user", &cacheUser{Name: "foo", Age: 11}))
+	user := &cacheUser{}
+	require.NoError(t, cache.GetObj(ctx, "user", user))
+	assert.Equal(t, cacheUser{Name: "foo", Age: 11}, *user)
+	ttl, err = cache.GetTTL(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), ttl)
+
+	require.NoError(t, cache.SetMany(ctx, map[string]string{"a": "1", "b": "2"}, time.Hour))
+	vals, err := cache.GetMany(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, vals)
+
+	n, err := cache.Incr(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = cache.Decr(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), n)
+	_, err = cache.Incr(ctx, "foo")
+	assert.ErrorIs(t, err, contract.ErrTypeNotOk)
+
+	calls := 0
+	remember := func(ctx context.Context, container framework.Container) (interface{}, error) {
+		calls++
+		return &cacheUser{Name: "bar", Age: 12}, nil
+	}
+	for i := 0; i < 2; i++ {
+		user := &cacheUser{}
+		require.NoError(t, cache.Remember(ctx, "remember", time.Hour, remember, user))
+		assert.Equal(t, "bar", user.Name)
+	}
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, cache.Delete(ctx, "foo"))
+	require.NoError(t, cache.DeleteMany(ctx, []string{"a", "b"}))
+	vals, err = cache.GetMany(ctx, []string{"foo", "a", "b"})
+	require.NoError(t, err)
+	assert.Empty(t, vals)
+}
+
+func TestMemoryCache(t *testing.T) {
+	ins, err := NewMemoryCache(framework.NewContainer())
+	require.NoError(t, err)
+	testCacheService(t, ins.(*MemoryCache))
+}
+
+func TestMemoryCache_LRU(t *testing.T) {
+	ctx := context.Background()
+	cache := &MemoryCache{capacity: 2, items: map[string]*list.Element{}, ll: list.New()}
+
+	require.NoError(t, cache.SetForever(ctx, "a", "1"))
+	require.NoError(t, cache.SetForever(ctx, "b", "2"))
+	// 访问a之后，b成为最久未使用的key
+	_, err := cache.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, cache.SetForever(ctx, "c", "3"))
+
+	_, err = cache.Get(ctx, "b")
+	assert.ErrorIs(t, err, contract.ErrKeyNotFound)
+	vals, err := cache.GetMany(ctx, []string{"a", "c"})
+	require.NoError(t, err)
+	assert.Len(t, vals, 2)
+
+	// 过期的key读取不到
+	require.NoError(t, cache.Set(ctx, "d", "4", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, err = cache.Get(ctx, "d")
+	assert.ErrorIs(t, err, contract.ErrKeyNotFound)
+}
+
+func TestFileCache(t *testing.T) {
+	testCacheService(t, &FileCache{container: framework.NewContainer(), folder: t.TempDir()})
+}
+
+func TestRedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	testCacheService(t, &RedisCache{container: framework.NewContainer(), client: client})
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/util"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileData 文件缓存中每个文件保存的内容
+type FileData struct {
+	Key     string `json:"key"`
+	Val     string `json:"val"`
+	Expired int64  `json:"expired"` // 过期时间的UnixNano, 为0的时候表示永不过期
+}
+
+// isExpired 是否已经过期
+func (d *FileData) isExpired(now time.Time) bool {
+	return d.Expired != 0 && now.UnixNano() > d.Expired
+}
+
+// FileCache 使用文件存储的缓存, 每个key保存为一个文件, 默认目录为 RuntimeFolder/cache
+type FileCache struct {
+	container framework.Container
+	folder    string // 缓存文件存放目录
+
+	lock sync.RWMutex
+}
+
+// NewFileCache 初始化FileCache, 读取cache.folder作为缓存目录
+func NewFileCache(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+	appService := framework.MustMake[contract.App](container)
+	configService := framework.MustMake[contract.Config](container)
+
+	folder := filepath.Join(appService.RuntimeFolder(), "cache")
+	if configService.IsExist("cache.folder") {
+		folder = configService.GetString("cache.folder")
+		// 相对路径相对于RuntimeFolder
+		if !filepath.IsAbs(folder) {
+			folder = filepath.Join(appService.RuntimeFolder(), folder)
+		}
+	}
+	if !util.Exists(folder) {
+		if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+			return nil, errors.Wrap(err, "create cache folder error")
+		}
+	}
+
+	return &FileCache{container: container, folder: folder}, nil
+}
+
+// file 获取某个key对应的文件地址, key中可能带有路径分隔符，所以使用md5作为文件名
+func (f *FileCache) file(key string) string {
+	sum := md5.Sum([]byte(key))
+	return filepath.Join(f.folder, hex.EncodeToString(sum[:]))
+}
+
+// read 读取某个未过期的数据, 调用方需要持有锁
+func (f *FileCache) read(key string) (*FileData, error) {
+	bt, err := os.ReadFile(f.file(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, contract.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	data := &FileData{}
+	if err := json.Unmarshal(bt, data); err != nil {
+		return nil, err
+	}
+	if data.isExpired(time.Now()) {
+		os.Remove(f.file(key))
+		return nil, contract.ErrKeyNotFound
+	}
+	return data, nil
+}
+
+// write 写入某个数据, 先写临时文件再重命名，防止读到写了一半的文件, 调用方需要持有锁
+func (f *FileCache) write(data *FileData) error {
+	bt, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	file := f.file(data.Key)
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, bt, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// remove 删除某个key对应的文件, 调用方需要持有锁
+func (f *FileCache) remove(key string) error {
+	if err := os.Remove(f.file(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// expired 根据timeout计算过期时间
+func expired(timeout time.Duration) int64 {
+	if timeout <= 0 {
+		return 0
+	}
+	return time.Now().Add(timeout).UnixNano()
+}
+
+// Get 获取某个key对应的值
+func (f *FileCache) Get(ctx context.Context, key string) (string, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	data, err := f.read(key)
+	if err != nil {
+		return "", err
+	}
+	return data.Val, nil
+}
+
+// GetObj 获取某个key对应的对象
+func (f *FileCache) GetObj(ctx context.Context, key string, model interface{}) error {
+	return getObj(ctx, f.Get, key, model)
+}
+
+// GetMany 获取多个key对应的值
+func (f *FileCache) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	ret := map[string]string{}
+	for _, key := range keys {
+		data, err := f.read(key)
+		if err != nil {
+			if errors.Is(err, contract.ErrKeyNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		ret[key] = data.Val
+	}
+	return ret, nil
+}
+
+// Set 设置某个key和值
+func (f *FileCache) Set(ctx context.Context, key string, val string, timeout time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.write(&FileData{Key: key, Val: val, Expired: expired(timeout)})
+}
+
+// SetObj 设置某个key和对象
+func (f *FileCache) SetObj(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return setObj(ctx, f.Set, key, val, timeout)
+}
+
+// SetMany 设置多个key和值
+func (f *FileCache) SetMany(ctx context.Context, data map[string]string, timeout time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	exp := expired(timeout)
+	for key, val := range data {
+		if err := f.write(&FileData{Key: key, Val: val, Expired: exp}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetForever 设置某个key和值，永不过期
+func (f *FileCache) SetForever(ctx context.Context, key string, val string) error {
+	return f.Set(ctx, key, val, 0)
+}
+
+// SetForeverObj 设置某个key和对象，永不过期
+func (f *FileCache) SetForeverObj(ctx context.Context, key string, val interface{}) error {
+	return f.SetObj(ctx, key, val, 0)
+}
+
+// SetTTL 设置某个key的过期时间
+func (f *FileCache) SetTTL(ctx context.Context, key string, timeout time.Duration) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	data, err := f.read(key)
+	if err != nil {
+		return err
+	}
+	data.Expired = expired(timeout)
+	return f.write(data)
+}
+
+// GetTTL 获取某个key的剩余过期时间
+func (f *FileCache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	data, err := f.read(key)
+	if err != nil {
+		return 0, err
+	}
+	if data.Expired == 0 {
+		return -1, nil
+	}
+	return time.Until(time.Unix(0, data.Expired)), nil
+}
+
+// Remember 实现Cache-Aside模式
+func (f *FileCache) Remember(ctx context.Context, key string, timeout time.Duration, rememberFunc contract.RememberFunc, model interface{}) error {
+	return remember(ctx, f, f.container, key, timeout, rememberFunc, model)
+}
+
+// Calc 往key对应的值中增加step计数
+func (f *FileCache) Calc(ctx context.Context, key string, step int64) (int64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	data, err := f.read(key)
+	if err != nil {
+		if !errors.Is(err, contract.ErrKeyNotFound) {
+			return 0, err
+		}
+		data = &FileData{Key: key, Val: "0"}
+	}
+	current, err := strconv.ParseInt(data.Val, 10, 64)
+	if err != nil {
+		return 0, contract.ErrTypeNotOk
+	}
+	current = current + step
+	data.Val = strconv.FormatInt(current, 10)
+	if err := f.write(data); err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+// Incr 往key对应的值中增加1
+func (f *FileCache) Incr(ctx context.Context, key string) (int64, error) {
+	return f.Calc(ctx, key, 1)
+}
+
+// Decr 往key对应的值中减去1
+func (f *FileCache) Decr(ctx context.Context, key string) (int64, error) {
+	return f.Calc(ctx, key, -1)
+}
+
+// Delete 删除某个key
+func (f *FileCache) Delete(ctx context.Context, key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.remove(key)
+}
+
+// DeleteMany 删除多个key
+func (f *FileCache) DeleteMany(ctx context.Context, keys []string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, key := range keys {
+		if err := f.remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
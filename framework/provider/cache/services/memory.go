@@ -0,0 +1,257 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMemoryCapacity 内存缓存默认最多保存的key数量
+const defaultMemoryCapacity = 10000
+
+// MemoryData 内存缓存中保存的一个数据
+type MemoryData struct {
+	key         string
+	val         string
+	expiredTime time.Time // 过期时间, 为零值的时候表示永不过期
+}
+
+// isExpired 是否已经过期
+func (d *MemoryData) isExpired(now time.Time) bool {
+	return !d.expiredTime.IsZero() && now.After(d.expiredTime)
+}
+
+// MemoryCache 使用LRU淘汰策略的内存缓存
+type MemoryCache struct {
+	container framework.Container
+	capacity  int                      // 最多保存的key数量
+	items     map[string]*list.Element // key对应链表中的节点
+	ll        *list.List               // 链表头部为最近使用的数据
+
+	lock sync.Mutex
+}
+
+// NewMemoryCache 初始化MemoryCache, 读取cache.capacity作为最大容量
+func NewMemoryCache(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+
+	capacity := defaultMemoryCapacity
+	if container.IsBind(contract.ConfigKey) {
+		configService := framework.MustMake[contract.Config](container)
+		if configService.IsExist("cache.capacity") {
+			capacity = configService.GetInt("cache.capacity")
+		}
+	}
+
+	obj := &MemoryCache{
+		container: container,
+		capacity:  capacity,
+		items:     map[string]*list.Element{},
+		ll:        list.New(),
+	}
+	return obj, nil
+}
+
+// expiredTime 根据timeout计算过期时间
+func expiredTime(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// get 获取某个未过期的数据，并将其移动到链表头部, 调用方需要持有锁
+func (m *MemoryCache) get(key string) (*MemoryData, bool) {
+	ele, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	data := ele.Value.(*MemoryData)
+	if data.isExpired(time.Now()) {
+		m.removeElement(ele)
+		return nil, false
+	}
+	m.ll.MoveToFront(ele)
+	return data, true
+}
+
+// set 设置某个数据，超出容量的时候淘汰最久未使用的数据, 调用方需要持有锁
+func (m *MemoryCache) set(key string, val string, expired time.Time) {
+	if ele, ok := m.items[key]; ok {
+		data := ele.Value.(*MemoryData)
+		data.val = val
+		data.expiredTime = expired
+		m.ll.MoveToFront(ele)
+		return
+	}
+
+	ele := m.ll.PushFront(&MemoryData{key: key, val: val, expiredTime: expired})
+	m.items[key] = ele
+	for m.capacity > 0 && m.ll.Len() > m.capacity {
+		m.removeElement(m.ll.Back())
+	}
+}
+
+// removeElement 删除链表中的某个节点, 调用方需要持有锁
+func (m *MemoryCache) removeElement(ele *list.Element) {
+	m.ll.Remove(ele)
+	delete(m.items, ele.Value.(*MemoryData).key)
+}
+
+// Get 获取某个key对应的值
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data, ok := m.get(key)
+	if !ok {
+		return "", contract.ErrKeyNotFound
+	}
+	return data.val, nil
+}
+
+// GetObj 获取某个key对应的对象
+func (m *MemoryCache) GetObj(ctx context.Context, key string, model interface{}) error {
+	return getObj(ctx, m.Get, key, model)
+}
+
+// GetMany 获取多个key对应的值
+func (m *MemoryCache) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ret := map[string]string{}
+	for _, key := range keys {
+		if data, ok := m.get(key); ok {
+			ret[key] = data.val
+		}
+	}
+	return ret, nil
+}
+
+// Set 设置某个key和值
+func (m *MemoryCache) Set(ctx context.Context, key string, val string, timeout time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.set(key, val, expiredTime(timeout))
+	return nil
+}
+
+// SetObj 设置某个key和对象
+func (m *MemoryCache) SetObj(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return setObj(ctx, m.Set, key, val, timeout)
+}
+
+// SetMany 设置多个key和值
+func (m *MemoryCache) SetMany(ctx context.Context, data map[string]string, timeout time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	expired := expiredTime(timeout)
+	for key, val := range data {
+		m.set(key, val, expired)
+	}
+	return nil
+}
+
+// SetForever 设置某个key和值，永不过期
+func (m *MemoryCache) SetForever(ctx context.Context, key string, val string) error {
+	return m.Set(ctx, key, val, 0)
+}
+
+// SetForeverObj 设置某个key和对象，永不过期
+func (m *MemoryCache) SetForeverObj(ctx context.Context, key string, val interface{}) error {
+	return m.SetObj(ctx, key, val, 0)
+}
+
+// SetTTL 设置某个key的过期时间
+func (m *MemoryCache) SetTTL(ctx context.Context, key string, timeout time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data, ok := m.get(key)
+	if !ok {
+		return contract.ErrKeyNotFound
+	}
+	data.expiredTime = expiredTime(timeout)
+	return nil
+}
+
+// GetTTL 获取某个key的剩余过期时间
+func (m *MemoryCache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data, ok := m.get(key)
+	if !ok {
+		return 0, contract.ErrKeyNotFound
+	}
+	if data.expiredTime.IsZero() {
+		return -1, nil
+	}
+	return time.Until(data.expiredTime), nil
+}
+
+// Remember 实现Cache-Aside模式
+func (m *MemoryCache) Remember(ctx context.Context, key string, timeout time.Duration, rememberFunc contract.RememberFunc, model interface{}) error {
+	return remember(ctx, m, m.container, key, timeout, rememberFunc, model)
+}
+
+// Calc 往key对应的值中增加step计数
+func (m *MemoryCache) Calc(ctx context.Context, key string, step int64) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var current int64
+	var expired time.Time
+	if data, ok := m.get(key); ok {
+		val, err := strconv.ParseInt(data.val, 10, 64)
+		if err != nil {
+			return 0, contract.ErrTypeNotOk
+		}
+		current = val
+		expired = data.expiredTime
+	}
+	current = current + step
+	m.set(key, strconv.FormatInt(current, 10), expired)
+	return current, nil
+}
+
+// Incr 往key对应的值中增加1
+func (m *MemoryCache) Incr(ctx context.Context, key string) (int64, error) {
+	return m.Calc(ctx, key, 1)
+}
+
+// Decr 往key对应的值中减去1
+func (m *MemoryCache) Decr(ctx context.Context, key string) (int64, error) {
+	return m.Calc(ctx, key, -1)
+}
+
+// Delete 删除某个key
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if ele, ok := m.items[key]; ok {
+		m.removeElement(ele)
+	}
+	return nil
+}
+
+// DeleteMany 删除多个key
+func (m *MemoryCache) DeleteMany(ctx context.Context, keys []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, key := range keys {
+		if ele, ok := m.items[key]; ok {
+			m.removeElement(ele)
+		}
+	}
+	return nil
+}
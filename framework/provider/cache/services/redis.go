@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	redisProvider "goweb/framework/provider/redis"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisCache 使用redis作为存储的缓存
+type RedisCache struct {
+	container framework.Container
+	client    *redis.Client
+}
+
+// NewRedisCache 初始化redis缓存, 使用redis服务获取连接
+// 如果配置了cache.connection，使用redis.yaml中对应名字的连接，否则读取cache.yaml中的host/port/password/db
+func NewRedisCache(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+	if !container.IsBind(contract.RedisKey) {
+		return nil, errors.New("redis service not bind, please bind redis.RedisProvider")
+	}
+	configService := framework.MustMake[contract.Config](container)
+	redisService := framework.MustMake[contract.RedisService](container)
+
+	opt := redisProvider.WithConnectionPath("cache")
+	if configService.IsExist("cache.connection") {
+		opt = redisProvider.WithConnection(configService.GetString("cache.connection"))
+	}
+	client, err := redisService.GetClient(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisCache{
+		container: container,
+		client:    client,
+	}, nil
+}
+
+// Get 获取某个key对应的值
+func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	val, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return val, contract.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// GetObj 获取某个key对应的对象
+func (r *RedisCache) GetObj(ctx context.Context, key string, model interface{}) error {
+	return getObj(ctx, r.Get, key, model)
+}
+
+// GetMany 获取多个key对应的值
+func (r *RedisCache) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	pipeline := r.client.Pipeline()
+	vals := make(map[string]string)
+	cmds := make([]*redis.StringCmd, 0, len(keys))
+
+	for _, key := range keys {
+		cmds = append(cmds, pipeline.Get(ctx, key))
+	}
+
+	_, err := pipeline.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		val, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		key := cmd.Args()[1].(string)
+		vals[key] = val
+	}
+	return vals, nil
+}
+
+// Set 设置某个key和值
+func (r *RedisCache) Set(ctx context.Context, key string, val string, timeout time.Duration) error {
+	return r.client.Set(ctx, key, val, timeout).Err()
+}
+
+// SetObj 设置某个key和对象
+func (r *RedisCache) SetObj(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return setObj(ctx, r.Set, key, val, timeout)
+}
+
+// SetMany 设置多个key和值
+func (r *RedisCache) SetMany(ctx context.Context, data map[string]string, timeout time.Duration) error {
+	pipeline := r.client.Pipeline()
+	for k, v := range data {
+		pipeline.Set(ctx, k, v, timeout)
+	}
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+// SetForever 设置某个key和值，永不过期
+func (r *RedisCache) SetForever(ctx context.Context, key string, val string) error {
+	return r.client.Set(ctx, key, val, 0).Err()
+}
+
+// SetForeverObj 设置某个key和对象，永不过期
+func (r *RedisCache) SetForeverObj(ctx context.Context, key string, val interface{}) error {
+	return r.SetObj(ctx, key, val, 0)
+}
+
+// SetTTL 设置某个key的过期时间
+func (r *RedisCache) SetTTL(ctx context.Context, key string, timeout time.Duration) error {
+	ok, err := r.client.Expire(ctx, key, timeout).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return contract.ErrKeyNotFound
+	}
+	return nil
+}
+
+// GetTTL 获取某个key的剩余过期时间
+func (r *RedisCache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// redis对于不存在的key返回-2, 对于永不过期的key返回-1
+	if ttl == -2 {
+		return 0, contract.ErrKeyNotFound
+	}
+	return ttl, nil
+}
+
+// Remember 实现Cache-Aside模式
+func (r *RedisCache) Remember(ctx context.Context, key string, timeout time.Duration, rememberFunc contract.RememberFunc, model interface{}) error {
+	return remember(ctx, r, r.container, key, timeout, rememberFunc, model)
+}
+
+// Calc 往key对应的值中增加step计数
+func (r *RedisCache) Calc(ctx context.Context, key string, step int64) (int64, error) {
+	val, err := r.client.IncrBy(ctx, key, step).Result()
+	if err != nil {
+		if _, ok := err.(redis.Error); ok {
+			return 0, contract.ErrTypeNotOk
+		}
+		return 0, err
+	}
+	return val, nil
+}
+
+// Incr 往key对应的值中增加1
+func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	return r.Calc(ctx, key, 1)
+}
+
+// Decr 往key对应的值中减去1
+func (r *RedisCache) Decr(ctx context.Context, key string) (int64, error) {
+	return r.Calc(ctx, key, -1)
+}
+
+// Delete 删除某个key
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+// DeleteMany 删除多个key
+func (r *RedisCache) DeleteMany(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
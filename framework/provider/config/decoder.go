@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder 将配置文件的内容解析为配置结构
+type Decoder func(content []byte) (map[string]interface{}, error)
+
+var (
+	// decoders 配置文件后缀对应的解析方法, key为不带点的小写后缀
+	decoders = map[string]Decoder{
+		"yaml":       decodeYaml,
+		"yml":        decodeYaml,
+		"json":       decodeJson,
+		"toml":       decodeToml,
+		"properties": decodeProperties,
+		"ini":        decodeProperties,
+	}
+	decodersLock sync.RWMutex
+)
+
+// RegisterDecoder 注册某个后缀的配置文件的解析方法, 已经存在的后缀会被替换
+// ext为不带点的后缀, 比如 "hcl"
+func RegisterDecoder(ext string, decoder Decoder) {
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+	decoders[strings.ToLower(strings.TrimPrefix(ext, "."))] = decoder
+}
+
+// getDecoder 获取某个后缀的配置文件的解析方法
+func getDecoder(ext string) (Decoder, bool) {
+	decodersLock.RLock()
+	defer decodersLock.RUnlock()
+	decoder, ok := decoders[strings.ToLower(ext)]
+	return decoder, ok
+}
+
+// parseConfigFileName 解析配置文件名, 返回去掉后缀的配置名和对应的解析方法
+// 配置名可以包含点, 比如 app.local.yaml 的配置名为 app.local, 其中的配置项可以通过 app.local.xxx 获取
+// 隐藏文件和没有注册解析方法的文件返回false
+func parseConfigFileName(file string) (string, Decoder, bool) {
+	index := strings.LastIndex(file, ".")
+	if index <= 0 || strings.HasPrefix(file, ".") {
+		return "", nil, false
+	}
+	decoder, ok := getDecoder(file[index+1:])
+	if !ok {
+		return "", nil, false
+	}
+	return file[:index], decoder, true
+}
+
+// IsConfigFile 判断是否为注册了解析方法的配置文件
+func IsConfigFile(file string) bool {
+	_, _, ok := parseConfigFileName(file)
+	return ok
+}
+
+// decodeYaml 解析yaml格式的配置文件
+func decodeYaml(content []byte) (map[string]interface{}, error) {
+	conf := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// decodeJson 解析json格式的配置文件
+func decodeJson(content []byte) (map[string]interface{}, error) {
+	conf := map[string]interface{}{}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return conf, nil
+	}
+	if err := json.Unmarshal(content, &conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// decodeToml 解析toml格式的配置文件
+func decodeToml(content []byte) (map[string]interface{}, error) {
+	conf := map[string]interface{}{}
+	if err := toml.Unmarshal(content, &conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// decodeProperties 解析properties和ini格式的配置文件, 也可以用于.env格式的文件
+// 每行为 key=value 或者 key: value, 以#或者;开头的行为注释
+// [section] 表示之后的配置项都在section下, key中的点表示层级, 比如 a.b=c 等同于 a: {b: c}
+func decodeProperties(content []byte) (map[string]interface{}, error) {
+	conf := map[string]interface{}{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		index := strings.IndexAny(text, "=:")
+		if index <= 0 {
+			return nil, fmt.Errorf("line %d: invalid property %q", line, text)
+		}
+		key := strings.TrimSpace(text[:index])
+		if section != "" {
+			key = section + "." + key
+		}
+		setPath(conf, strings.Split(key, "."), unquote(strings.TrimSpace(text[index+1:])))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// unquote 去掉值两边的引号
+func unquote(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// setPath 按照路径设置map中的值, 中间不存在的层级会被创建
+func setPath(conf map[string]interface{}, path []string, val interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := conf[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			conf[key] = next
+		}
+		conf = next
+	}
+	conf[path[len(path)-1]] = val
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"goweb/framework"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigFileName(t *testing.T) {
+	cases := []struct {
+		file string
+		name string
+		ok   bool
+	}{
+		{"app.yaml", "app", true},
+		{"app.YML", "app", true},
+		{"app.local.yaml", "app.local", true},
+		{"database.json", "database", true},
+		{"cache.toml", "cache", true},
+		{"redis.properties", "redis", true},
+		{"log.ini", "log", true},
+		{"index.js", "", false},
+		{".app.yaml", "", false},
+		{"app", "", false},
+	}
+	for _, c := range cases {
+		name, _, ok := parseConfigFileName(c.file)
+		assert.Equal(t, c.ok, ok, c.file)
+		assert.Equal(t, c.name, name, c.file)
+	}
+}
+
+func TestDecodeProperties(t *testing.T) {
+	conf, err := decodeProperties([]byte(`
+# 注释
+; 注释
+name = goweb
+url: "http://127.0.0.1"
+dev.port=8070
+
+[redis]
+host = 127.0.0.1
+pool.size = '10'
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": "goweb",
+		"url":  "http://127.0.0.1",
+		"dev":  map[string]interface{}{"port": "8070"},
+		"redis": map[string]interface{}{
+			"host": "127.0.0.1",
+			"pool": map[string]interface{}{"size": "10"},
+		},
+	}, conf)
+
+	_, err = decodeProperties([]byte("name\n"))
+	assert.EqualError(t, err, `line 1: invalid property "name"`)
+}
+
+func TestConfig_Decoders(t *testing.T) {
+	base := t.TempDir()
+	files := map[string]string{
+		"app.yaml":         "name: goweb\nlocal:\n  debug: false\n  port: 8080\n",
+		"app.local.yaml":   "debug: true\n",
+		"database.json":    `{"driver": "mysql", "pool": {"max_open": 10}}`,
+		"cache.toml":       "driver = \"redis\"\n[redis]\nhost = \"127.0.0.1\"\nport = 6379\n",
+		"redis.properties": "host=localhost\ntimeout = 5s\n",
+		"log.ini":          "[rotate]\nmax_age = 10d\n",
+		"index.js":         "module.exports = {}",
+	}
+	for file, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(base, file), []byte(content), 0644))
+	}
+	RegisterDecoder(".hcl", decodeProperties)
+	defer func() {
+		decodersLock.Lock()
+		delete(decoders, "hcl")
+		decodersLock.Unlock()
+	}()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "custom.hcl"), []byte("name = custom\n"), 0644))
+
+	ins, err := NewConfig(framework.NewContainer(), base, "testing", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+
+	assert.Equal(t, "goweb", conf.GetString("app.name"))
+	assert.True(t, conf.GetBool("app.local.debug"))
+	assert.Equal(t, 8080, conf.GetInt("app.local.port"))
+	assert.Equal(t, "mysql", conf.GetString("database.driver"))
+	assert.Equal(t, 10, conf.GetInt("database.pool.max_open"))
+	assert.Equal(t, "redis", conf.GetString("cache.driver"))
+	assert.Equal(t, 6379, conf.GetInt("cache.redis.port"))
+	assert.Equal(t, "localhost", conf.GetString("redis.host"))
+	assert.Equal(t, "10d", conf.GetString("log.rotate.max_age"))
+	assert.Equal(t, "custom", conf.GetString("custom.name"))
+	assert.False(t, conf.IsExist("index"))
+
+	sources := conf.Explain("app.local.debug")
+	require.Len(t, sources, 1)
+	assert.Equal(t, filepath.Join(base, "app.local.yaml"), sources[0].File)
+	sources = conf.Explain("app.local.port")
+	require.Len(t, sources, 1)
+	assert.Equal(t, filepath.Join(base, "app.yaml"), sources[0].File)
+
+	// 删除文件之后配置也被删除
+	conf.notify(func() {
+		require.NoError(t, os.Remove(filepath.Join(base, "app.local.yaml")))
+		conf.removeConfigFile(base, "app.local.yaml")
+	})
+	assert.False(t, conf.GetBool("app.local.debug"))
+	assert.Equal(t, 8080, conf.GetInt("app.local.port"))
+}
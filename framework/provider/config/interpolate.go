@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// InterpolateError 替换配置文件中的环境变量失败时返回的错误, 包含出错的文件和行号
+type InterpolateError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *InterpolateError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// replace 使用环境变量替换配置文件内容中的变量, 支持以下写法:
+//
+//	${KEY}              环境变量KEY的值, 不存在的时候为空字符串
+//	${KEY:-default}     环境变量KEY不存在或者为空的时候使用default, default中也可以使用变量
+//	${KEY:?message}     环境变量KEY不存在或者为空的时候加载失败, 返回message和所在的行号
+//	$${KEY}             转义, 输出 ${KEY}
+//	env(KEY)            兼容之前的写法, 环境变量KEY不存在的时候保留原样
+//
+// #和;开头的注释行保留原样, 注释掉的 ${KEY:?message} 不会导致加载失败
+func replace(content []byte, maps map[string]string) ([]byte, error) {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		if isCommentLine(line) {
+			continue
+		}
+		val, err := interpolate(line, maps, i+1)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = val
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// isCommentLine 判断是否为#或者;开头的注释行
+func isCommentLine(line []byte) bool {
+	trimmed := bytes.TrimSpace(line)
+	return bytes.HasPrefix(trimmed, []byte("#")) || bytes.HasPrefix(trimmed, []byte(";"))
+}
+
+// interpolate 替换content中的变量, line为content第一行在文件中的行号
+func interpolate(content []byte, maps map[string]string, line int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(content))
+	for i := 0; i < len(content); {
+		rest := content[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("$${")):
+			buf.WriteString("${")
+			i += 3
+		case bytes.HasPrefix(rest, []byte("${")):
+			end := matchBrace(content, i+2)
+			current := line + bytes.Count(content[:i], []byte("\n"))
+			if end < 0 {
+				return nil, &InterpolateError{Line: current, Message: "unclosed variable " + firstLine(rest)}
+			}
+			val, err := expandVariable(content[i+2:end], maps, current)
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(val)
+			i = end + 1
+		case bytes.HasPrefix(rest, []byte("env(")):
+			end := bytes.IndexByte(rest, ')')
+			if end < 0 {
+				buf.WriteByte(content[i])
+				i++
+				continue
+			}
+			if val, ok := maps[string(rest[4:end])]; ok {
+				buf.WriteString(val)
+			} else {
+				buf.Write(rest[:end+1])
+			}
+			i += end + 1
+		default:
+			buf.WriteByte(content[i])
+			i++
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// expandVariable 计算 ${...} 中间的表达式
+func expandVariable(expr []byte, maps map[string]string, line int) (string, error) {
+	key, op, arg := string(expr), "", []byte(nil)
+	if index := bytes.IndexByte(expr, ':'); index >= 0 && index+1 < len(expr) && (expr[index+1] == '-' || expr[index+1] == '?') {
+		key, op, arg = string(expr[:index]), string(expr[index:index+2]), expr[index+2:]
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", &InterpolateError{Line: line, Message: "empty variable name in ${" + string(expr) + "}"}
+	}
+
+	val := maps[key]
+	switch op {
+	case ":-":
+		if val != "" {
+			return val, nil
+		}
+		def, err := interpolate(arg, maps, line)
+		if err != nil {
+			return "", err
+		}
+		return string(def), nil
+	case ":?":
+		if val != "" {
+			return val, nil
+		}
+		message := strings.TrimSpace(string(arg))
+		if message == "" {
+			message = "is required"
+		}
+		return "", &InterpolateError{Line: line, Message: "env " + key + " " + message}
+	}
+	return val, nil
+}
+
+// matchBrace 从start开始查找和 ${ 匹配的 }, 中间可以嵌套 ${...}, 找不到的时候返回-1
+func matchBrace(content []byte, start int) int {
+	depth := 0
+	for i := start; i < len(content); i++ {
+		switch {
+		case content[i] == '\n':
+			return -1
+		case content[i] == '$' && i+1 < len(content) && content[i+1] == '{':
+			depth++
+			i++
+		case content[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// firstLine 返回内容的第一行, 用于错误信息
+func firstLine(content []byte) string {
+	if index := bytes.IndexByte(content, '\n'); index >= 0 {
+		return string(content[:index])
+	}
+	return string(content)
+}
+
+// EnvReference 配置文件中对环境变量的一次引用
+type EnvReference struct {
+	Name     string // 环境变量的名称
+	Line     int    // 所在的行号
+	Default  bool   // 是否有默认值, ${KEY:-default}, 环境变量不存在或者为空的时候使用默认值
+	Required bool   // 是否为必须的, ${KEY:?message}, 环境变量不存在或者为空的时候加载失败
+	Legacy   bool   // 是否为env(KEY)的写法, 没有默认值, 环境变量不存在的时候保留env(KEY)原样
+}
+
+// EnvReferences 查找配置文件内容中引用的所有环境变量, 跳过#和;开头的注释行
+func EnvReferences(content []byte) []EnvReference {
+	refs := []EnvReference{}
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if isCommentLine(line) {
+			continue
+		}
+		refs = append(refs, lineEnvReferences(line, i+1)...)
+	}
+	return refs
+}
+
+// lineEnvReferences 查找一行中引用的环境变量, 包括默认值中嵌套的引用
+func lineEnvReferences(content []byte, line int) []EnvReference {
+	refs := []EnvReference{}
+	for i := 0; i < len(content); {
+		rest := content[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("$${")):
+			i += 3
+		case bytes.HasPrefix(rest, []byte("${")):
+			end := matchBrace(content, i+2)
+			if end < 0 {
+				return refs
+			}
+			expr := content[i+2 : end]
+			ref := EnvReference{Name: strings.TrimSpace(string(expr)), Line: line}
+			if index := bytes.IndexByte(expr, ':'); index >= 0 && index+1 < len(expr) && (expr[index+1] == '-' || expr[index+1] == '?') {
+				ref.Name = strings.TrimSpace(string(expr[:index]))
+				ref.Default = expr[index+1] == '-'
+				ref.Required = expr[index+1] == '?'
+				if ref.Default {
+					refs = append(refs, lineEnvReferences(expr[index+2:], line)...)
+				}
+			}
+			if ref.Name != "" {
+				refs = append(refs, ref)
+			}
+			i = end + 1
+		case bytes.HasPrefix(rest, []byte("env(")):
+			end := bytes.IndexByte(rest, ')')
+			if end < 0 {
+				i++
+				continue
+			}
+			refs = append(refs, EnvReference{Name: string(rest[4:end]), Line: line, Legacy: true})
+			i += end + 1
+		default:
+			i++
+		}
+	}
+	return refs
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"goweb/framework"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplace(t *testing.T) {
+	envs := map[string]string{
+		"DB_HOST":     "127.0.0.1",
+		"DB_PASSWORD": "p@ss${word}",
+		"EMPTY":       "",
+	}
+	cases := []struct {
+		content string
+		want    string
+	}{
+		{"host: ${DB_HOST}", "host: 127.0.0.1"},
+		{"host: ${ DB_HOST }", "host: 127.0.0.1"},
+		{"host: ${NOT_EXIST}", "host: "},
+		{"port: ${DB_PORT:-3306}", "port: 3306"},
+		{"port: ${EMPTY:-3306}", "port: 3306"},
+		{"host: ${DB_HOST:-localhost}", "host: 127.0.0.1"},
+		{"url: ${DB_URL:-mysql://${DB_HOST}:${DB_PORT:-3306}}", "url: mysql://127.0.0.1:3306"},
+		{"password: ${DB_PASSWORD:?is required}", "password: p@ss${word}"},
+		{"literal: $${DB_HOST}", "literal: ${DB_HOST}"},
+		{"price: $5 {a}", "price: $5 {a}"},
+		{"password: env(DB_PASSWORD)", "password: p@ss${word}"},
+		{"password: env(NOT_EXIST)", "password: env(NOT_EXIST)"},
+		{"password: env(NOT_CLOSED", "password: env(NOT_CLOSED"},
+		// 注释行保留原样, 注释掉的必须变量不会导致加载失败
+		{"# password: ${NOT_EXIST:?is required}\nhost: ${DB_HOST}", "# password: ${NOT_EXIST:?is required}\nhost: 127.0.0.1"},
+		{"  ; password: ${NOT_EXIST:?is required}", "  ; password: ${NOT_EXIST:?is required}"},
+		{"a:\n  # b: ${NOT_EXIST:?\n  c: ${DB_HOST}", "a:\n  # b: ${NOT_EXIST:?\n  c: 127.0.0.1"},
+	}
+	for _, c := range cases {
+		got, err := replace([]byte(c.content), envs)
+		require.NoError(t, err, c.content)
+		assert.Equal(t, c.want, string(got), c.content)
+	}
+
+	errCases := []struct {
+		content string
+		err     string
+	}{
+		{"a: 1\nb: ${NOT_EXIST:?please set NOT_EXIST}", "line 2: env NOT_EXIST please set NOT_EXIST"},
+		{"a: ${EMPTY:?}", "line 1: env EMPTY is required"},
+		{"a: 1\n\nb: ${DB_HOST\nc: 1", "line 3: unclosed variable ${DB_HOST"},
+		{"a: ${:-1}", "line 1: empty variable name in ${:-1}"},
+		{"a: ${A:-${B:?nested}}", "line 1: env B nested"},
+		{"# a: ${B:?commented}\nb: ${B:?required}", "line 2: env B required"},
+	}
+	for _, c := range errCases {
+		_, err := replace([]byte(c.content), envs)
+		assert.EqualError(t, err, c.err, c.content)
+	}
+}
+
+func TestNewConfig_RequiredEnv(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, "database.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("driver: mysql\npassword: ${DB_PASSWORD:?must be set}\n"), 0644))
+
+	_, err := NewConfig(framework.NewContainer(), base, "testing", map[string]string{})
+	assert.EqualError(t, err, file+":2: env DB_PASSWORD must be set")
+
+	ins, err := NewConfig(framework.NewContainer(), base, "testing", map[string]string{"DB_PASSWORD": "123"})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	assert.Equal(t, "123", conf.GetString("database.password"))
+
+	// 重新加载失败的时候保留之前的配置
+	require.NoError(t, os.WriteFile(file, []byte("driver: mysql\npassword: ${OTHER:?must be set}\n"), 0644))
+	assert.Error(t, conf.loadConfigFile(base, "database.yaml"))
+	assert.Equal(t, "123", conf.GetString("database.password"))
+}
+
+func TestEnvReferences(t *testing.T) {
+	content := "# password: ${IN_COMMENT}\nhost: ${DB_HOST}\nport: ${DB_PORT:-${DEFAULT_PORT}}\npassword: ${DB_PASSWORD:?required}\nlegacy: env(LEGACY)\nliteral: $${ESCAPED}\n"
+	assert.Equal(t, []EnvReference{
+		{Name: "DB_HOST", Line: 2},
+		{Name: "DEFAULT_PORT", Line: 3},
+		{Name: "DB_PORT", Line: 3, Default: true},
+		{Name: "DB_PASSWORD", Line: 4, Required: true},
+		{Name: "LEGACY", Line: 5, Legacy: true},
+	}, EnvReferences([]byte(content)))
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"goweb/framework/contract"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// configLayer 代表一层配置, 比如根目录config/下的配置文件, 或者环境目录config/{env}/下的配置文件
+type configLayer struct {
+	name    string                 // 配置层的名称
+	folder  string                 // 配置层的文件夹, 默认值层为空
+	maps    map[string]interface{} // 配置文件结构, key为文件名
+	raws    map[string][]byte      // 配置文件的原始信息
+	files   map[string]string      // 配置文件的路径, key为文件名
+	secrets map[string][]string    // 配置文件中解密的配置项路径, key为文件名
+	conf    map[string]interface{} // 合并了这一层所有配置文件之后的配置结构
+}
+
+// newConfigLayer 初始化一个配置层
+func newConfigLayer(name string, folder string) *configLayer {
+	if folder != "" {
+		folder, _ = filepath.Abs(folder)
+	}
+	return &configLayer{
+		name:    name,
+		folder:  folder,
+		maps:    map[string]interface{}{},
+		raws:    map[string][]byte{},
+		files:   map[string]string{},
+		secrets: map[string][]string{},
+		conf:    map[string]interface{}{},
+	}
+}
+
+// merge 合并这一层所有的配置文件, 文件名中的点表示层级, 比如app.local.yaml中的配置合并到app.local下
+// 按照文件名排序合并, 所以app.local.yaml会覆盖app.yaml中local下的同名配置
+func (layer *configLayer) merge() {
+	names := make([]string, 0, len(layer.maps))
+	for name := range layer.maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conf := map[string]interface{}{}
+	for _, name := range names {
+		path := strings.Split(name, ".")
+		var value interface{} = layer.maps[name]
+		for i := len(path) - 1; i > 0; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		conf[path[0]] = mergeMap(conf[path[0]], value)
+	}
+	layer.conf = conf
+}
+
+// sourceFile 查找配置项来自这一层的哪个配置文件, 优先使用文件名最长的配置文件
+// 配置项包含了多个配置文件的时候, 返回所有的配置文件
+func (layer *configLayer) sourceFile(path []string) string {
+	names := make([]string, 0, len(layer.files))
+	for name := range layer.files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	files := []string{}
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		if len(parts) <= len(path) {
+			conf, _ := layer.maps[name].(map[string]interface{})
+			if isPrefix(parts, path) && conf != nil && searchMap(conf, path[len(parts):]) != nil {
+				return layer.files[name]
+			}
+		} else if isPrefix(path, parts) {
+			files = append(files, layer.files[name])
+		}
+	}
+	sort.Strings(files)
+	return strings.Join(files, ", ")
+}
+
+// isPrefix 判断prefix是否为path的前缀
+func isPrefix(prefix []string, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMap 将src深度合并到dst中, 两边都是map的时候递归合并, 否则使用src的值
+// 合并的时候不会修改dst和src, 返回合并之后的新值
+func mergeMap(dst interface{}, src interface{}) interface{} {
+	srcMap, ok := toStringMap(src)
+	if !ok {
+		return src
+	}
+	dstMap, ok := toStringMap(dst)
+	if !ok {
+		dstMap = map[string]interface{}{}
+	}
+	ret := make(map[string]interface{}, len(dstMap)+len(srcMap))
+	for k, v := range dstMap {
+		ret[k] = v
+	}
+	for k, v := range srcMap {
+		ret[k] = mergeMap(ret[k], v)
+	}
+	return ret
+}
+
+// toStringMap 将yaml解析出来的map转换为map[string]interface{}
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		return cast.ToStringMap(m), true
+	}
+	return nil, false
+}
+
+// SetDefault 设置某个属性的默认值, 默认值的优先级最低, 任何配置文件中的值都会覆盖默认值
+// 一般由服务提供者在Boot中设置
+func (conf *Config) SetDefault(key string, val interface{}) {
+	conf.notify(func() {
+		conf.lock.Lock()
+		defer conf.lock.Unlock()
+
+		path := strings.Split(key, conf.KeyDelim)
+		var value interface{} = val
+		for i := len(path) - 1; i > 0; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+		layer := conf.layers[0]
+		layer.maps[path[0]] = mergeMap(layer.maps[path[0]], value)
+		conf.mergeLayers()
+	})
+}
+
+// Explain 查找某个属性在每一层配置中的值, 按照优先级从高到低排列, 第一个就是生效的值
+// 属性的值为map的时候, 生效的值是所有配置层合并之后的结果
+func (conf *Config) Explain(key string) []contract.ConfigSource {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+
+	path := strings.Split(key, conf.KeyDelim)
+	ret := []contract.ConfigSource{}
+	for i := len(conf.layers) - 1; i >= 0; i-- {
+		layer := conf.layers[i]
+		val := searchMap(layer.conf, path)
+		if val == nil {
+			continue
+		}
+		ret = append(ret, contract.ConfigSource{
+			Layer: layer.name,
+			File:  layer.sourceFile(path),
+			Value: val,
+		})
+	}
+	return ret
+}
+
+// IsSecret 判断某个属性生效的值是否由配置文件中的加密值enc(...)解密得到
+// 打印配置的时候需要隐藏这些属性的值
+func (conf *Config) IsSecret(key string) bool {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+
+	path := strings.Split(key, conf.KeyDelim)
+	for i := len(conf.layers) - 1; i >= 0; i-- {
+		layer := conf.layers[i]
+		if searchMap(layer.conf, path) == nil {
+			continue
+		}
+		// 生效的值在这一层
+		for _, secrets := range layer.secrets {
+			for _, secret := range secrets {
+				if secret == key {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -3,7 +3,6 @@ package config
 import (
 	"goweb/framework"
 	"goweb/framework/contract"
-	"path/filepath"
 )
 
 type ConfigProvider struct{}
@@ -25,13 +24,12 @@ func (provider *ConfigProvider) IsDefer() bool {
 
 // Params define the necessary params for NewInstance
 func (provider *ConfigProvider) Params(c framework.Container) []interface{} {
-	appService := c.MustMake(contract.AppKey).(contract.App)
-	envService := c.MustMake(contract.EnvKey).(contract.Env)
+	appService := framework.MustMake[contract.App](c)
+	envService := framework.MustMake[contract.Env](c)
 	env := envService.AppEnv()
-	// 配置文件夹地址
+	// 配置文件夹地址, 环境目录和本地目录在配置文件夹下
 	configFolder := appService.ConfigFolder()
-	envFolder := filepath.Join(configFolder, env)
-	return []interface{}{c, envFolder, envService.All()}
+	return []interface{}{c, configFolder, env, envService.All(), appService.RuntimeFolder()}
 }
 
 /// Name define the name for this service
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"goweb/framework/contract"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+// schemas 注册的配置结构, key为配置项的路径
+var schemas = map[string]reflect.Type{}
+var schemasLock sync.RWMutex
+
+// RegisterSchema 注册某个配置项的结构, 一般由服务提供者在init中注册
+// schema为结构体或者结构体指针, 字段使用yaml tag对应配置项, validate tag定义校验规则
+// 例如: RegisterSchema("log", logSchema{}) 校验log.yaml中的配置
+func RegisterSchema(key string, schema interface{}) {
+	typ := reflect.TypeOf(schema)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic("config schema of " + key + " should be a struct")
+	}
+	schemasLock.Lock()
+	defer schemasLock.Unlock()
+	schemas[key] = typ
+}
+
+// schemaValidate 校验配置使用的validator, 字段名使用yaml tag
+var schemaValidate = newSchemaValidate()
+
+func newSchemaValidate() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return schemaFieldName(field)
+	})
+	// duration 校验字符串是否为合法的时间间隔, 例如: 10s, 24h
+	validate.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		if fl.Field().Kind() != reflect.String {
+			return true
+		}
+		val := fl.Field().String()
+		if val == "" {
+			return true
+		}
+		_, err := time.ParseDuration(val)
+		return err == nil
+	})
+	return validate
+}
+
+// schemaFieldName 结构体字段对应的配置项名称, 没有yaml tag的时候使用小写的字段名
+func schemaFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// Validate 按照注册的结构校验所有的配置项, 返回所有不符合的地方
+// 包括: 未知的配置项, 类型错误, 以及validate tag定义的校验规则
+func (conf *Config) Validate() []contract.ConfigViolation {
+	schemasLock.RLock()
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	schemasLock.RUnlock()
+	sort.Strings(keys)
+
+	violations := []contract.ConfigViolation{}
+	for _, key := range keys {
+		schemasLock.RLock()
+		typ := schemas[key]
+		schemasLock.RUnlock()
+		violations = append(violations, conf.validateSchema(key, typ)...)
+	}
+	return violations
+}
+
+// validateSchema 校验一个配置项
+func (conf *Config) validateSchema(key string, typ reflect.Type) []contract.ConfigViolation {
+	violations := []contract.ConfigViolation{}
+	reported := map[string]bool{}
+	addViolation := func(path string, message string) {
+		reported[path] = true
+		violations = append(violations, contract.ConfigViolation{
+			Key:     path,
+			File:    conf.violationFile(path),
+			Message: message,
+		})
+	}
+
+	raw := conf.Get(key)
+	if raw != nil {
+		if _, ok := toStringMap(raw); !ok {
+			addViolation(key, fmt.Sprintf("should be a map, got %T", raw))
+			return violations
+		}
+	}
+
+	// 未知的配置项, 比如大小写拼写错误
+	for _, path := range unknownKeys(key, raw, typ) {
+		addViolation(path, "unknown config key")
+	}
+
+	// 按照结构体解析配置, 类型不匹配的时候记录错误
+	val := reflect.New(typ)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "yaml",
+		WeaklyTypedInput: true,
+		Result:           val.Interface(),
+	})
+	if err != nil {
+		addViolation(key, err.Error())
+		return violations
+	}
+	if raw != nil {
+		if err := decoder.Decode(raw); err != nil {
+			for _, msg := range decodeErrors(err) {
+				addViolation(decodeErrorKey(key, msg), msg)
+			}
+		}
+	}
+
+	// 使用validate tag校验
+	if err := schemaValidate.Struct(val.Interface()); err != nil {
+		validateErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			addViolation(key, err.Error())
+			return violations
+		}
+		for _, fe := range validateErrs {
+			path := key
+			// Namespace的第一段为结构体的名称
+			if index := strings.Index(fe.Namespace(), "."); index >= 0 {
+				path = key + "." + fe.Namespace()[index+1:]
+			}
+			// 类型错误的配置项已经记录过了, 解析失败的值为零值, 不再重复校验
+			if reported[path] {
+				continue
+			}
+			addViolation(path, validateMessage(fe))
+		}
+	}
+	return violations
+}
+
+// violationFile 查找配置项生效的配置文件, 配置项不存在的时候查找上一级
+func (conf *Config) violationFile(key string) string {
+	for key != "" {
+		for _, source := range conf.Explain(key) {
+			if source.File != "" {
+				return source.File
+			}
+		}
+		// 数组中的一项查找整个数组, 例如: log.sinks[0] 查找 log.sinks
+		if index := strings.LastIndex(key, "["); index > 0 && strings.HasSuffix(key, "]") {
+			key = key[:index]
+			continue
+		}
+		index := strings.LastIndex(key, conf.KeyDelim)
+		if index < 0 {
+			break
+		}
+		key = key[:index]
+	}
+	return ""
+}
+
+// unknownKeys 查找配置中有, 但是结构体中没有定义的配置项
+func unknownKeys(prefix string, raw interface{}, typ reflect.Type) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	// 数组中的每一项, 例如: log.sinks[0].driver
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		ret := []string{}
+		if items, ok := raw.([]interface{}); ok {
+			for i, item := range items {
+				ret = append(ret, unknownKeys(fmt.Sprintf("%s[%d]", prefix, i), item, typ.Elem())...)
+			}
+		}
+		return ret
+	}
+	conf, ok := toStringMap(raw)
+	if !ok {
+		return nil
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if name := schemaFieldName(field); name != "" {
+			fields[name] = field
+		}
+	}
+	ret := []string{}
+	for name, val := range conf {
+		field, ok := fields[name]
+		if !ok {
+			ret = append(ret, prefix+"."+name)
+			continue
+		}
+		ret = append(ret, unknownKeys(prefix+"."+name, val, field.Type)...)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// decodeErrors 展开mapstructure返回的多个错误
+func decodeErrors(err error) []string {
+	if decodeErr, ok := err.(*mapstructure.Error); ok {
+		return decodeErr.Errors
+	}
+	return []string{err.Error()}
+}
+
+// decodeErrorField 匹配mapstructure错误信息中的字段名
+// 例如: 'port' expected type 'int', cannot parse 'server.port' as int
+var decodeErrorField = regexp.MustCompile(`'([^']*)'`)
+
+func decodeErrorKey(key string, msg string) string {
+	if match := decodeErrorField.FindStringSubmatch(msg); match != nil && match[1] != "" {
+		return key + "." + match[1]
+	}
+	return key
+}
+
+// validateMessage 校验失败的提示信息
+func validateMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("should be one of [%s], got '%v'", fe.Param(), fe.Value())
+	case "duration":
+		return fmt.Sprintf("should be a duration like 10s or 24h, got '%v'", fe.Value())
+	case "excluded_with":
+		return fmt.Sprintf("can not be set together with %s", snakeCase(fe.Param()))
+	case "required_if":
+		// 参数为 字段名 值, 例如: Driver multi
+		if parts := strings.Fields(fe.Param()); len(parts) == 2 {
+			return fmt.Sprintf("is required when %s is %s", snakeCase(parts[0]), parts[1])
+		}
+		return "is required"
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed on '%s=%s' validation, got '%v'", fe.Tag(), fe.Param(), fe.Value())
+	}
+	return fmt.Sprintf("failed on '%s' validation, got '%v'", fe.Tag(), fe.Value())
+}
+
+// snakeCase 将校验规则参数中的字段名转换为配置项的名称, 例如: RotateCount 转换为 rotate_count
+func snakeCase(name string) string {
+	var buf strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				buf.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
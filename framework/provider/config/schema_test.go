@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goweb/framework/contract"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServerSchema struct {
+	Host string `yaml:"host" validate:"required"`
+	Port int    `yaml:"port" validate:"gt=0,lte=65535"`
+}
+
+type testSchema struct {
+	Driver  string           `yaml:"driver" validate:"omitempty,oneof=memory redis"`
+	Timeout string           `yaml:"timeout" validate:"duration"`
+	Server  testServerSchema `yaml:"server"`
+}
+
+func TestConfig_Validate(t *testing.T) {
+	RegisterSchema("schematest", &testSchema{})
+	defer func() {
+		schemasLock.Lock()
+		delete(schemas, "schematest")
+		schemasLock.Unlock()
+	}()
+
+	base := t.TempDir()
+	baseFile := filepath.Join(base, "schematest.yaml")
+	require.NoError(t, os.WriteFile(baseFile, []byte("driver: memory\ntimeout: 1s\nserver:\n  host: 127.0.0.1\n  port: 80\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "production"), 0755))
+	envFile := filepath.Join(base, "production", "schematest.yaml")
+	require.NoError(t, os.WriteFile(envFile, []byte("Driver: redis\ntimeout: 10d\nserver:\n  port: abc\n"), 0644))
+
+	ins, err := NewConfig(nil, base, "development", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	assert.Empty(t, conf.Validate())
+	require.NoError(t, conf.Shutdown(context.Background()))
+
+	ins, err = NewConfig(nil, base, "production", map[string]string{})
+	require.NoError(t, err)
+	conf = ins.(*Config)
+	defer conf.Shutdown(context.Background())
+
+	violations := map[string]contract.ConfigViolation{}
+	for _, violation := range conf.Validate() {
+		violations[violation.Key] = violation
+	}
+	require.Len(t, violations, 3, "%v", violations)
+	assert.Equal(t, envFile, violations["schematest.Driver"].File)
+	assert.Equal(t, "unknown config key", violations["schematest.Driver"].Message)
+	assert.Equal(t, envFile, violations["schematest.timeout"].File)
+	assert.Contains(t, violations["schematest.timeout"].Message, "duration")
+	assert.Equal(t, envFile, violations["schematest.server.port"].File)
+}
+
+type testSinkSchema struct {
+	Driver string `yaml:"driver" validate:"required,oneof=console file"`
+}
+
+type testSinksSchema struct {
+	Driver string           `yaml:"driver"`
+	Sinks  []testSinkSchema `yaml:"sinks" validate:"required_if=Driver multi,dive"`
+}
+
+func TestConfig_ValidateSlice(t *testing.T) {
+	RegisterSchema("sinktest", &testSinksSchema{})
+	defer func() {
+		schemasLock.Lock()
+		delete(schemas, "sinktest")
+		schemasLock.Unlock()
+	}()
+
+	base := t.TempDir()
+	file := filepath.Join(base, "sinktest.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("driver: multi\nsinks:\n  - driver: console\n  - driver: kafka\n    levle: info\n"), 0644))
+	ins, err := NewConfig(nil, base, "development", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	violations := map[string]contract.ConfigViolation{}
+	for _, violation := range conf.Validate() {
+		violations[violation.Key] = violation
+	}
+	require.Len(t, violations, 2, "%v", violations)
+	assert.Equal(t, "unknown config key", violations["sinktest.sinks[1].levle"].Message)
+	assert.Equal(t, file, violations["sinktest.sinks[1].levle"].File)
+	assert.Contains(t, violations["sinktest.sinks[1].driver"].Message, "should be one of")
+	require.NoError(t, conf.Shutdown(context.Background()))
+
+	require.NoError(t, os.WriteFile(file, []byte("driver: multi\n"), 0644))
+	ins, err = NewConfig(nil, base, "development", map[string]string{})
+	require.NoError(t, err)
+	conf = ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	require.Len(t, conf.Validate(), 1)
+	assert.Equal(t, "is required when driver is multi", conf.Validate()[0].Message)
+}
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"goweb/framework/util"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SecretKeyEnv 保存配置密钥的环境变量, 值为base64编码的32字节密钥
+	SecretKeyEnv = "GOWEB_CONFIG_KEY"
+	// SecretKeyFileEnv 保存配置密钥文件路径的环境变量
+	SecretKeyFileEnv = "GOWEB_CONFIG_KEY_FILE"
+	// SecretKeyFile 默认的密钥文件, 在配置文件根目录下, 不提交到代码库
+	SecretKeyFile = "secret.key"
+)
+
+// secretPattern 匹配配置文件中的加密值, 例如: password: enc(base64密文)
+var secretPattern = regexp.MustCompile(`enc\(([A-Za-z0-9+/=]*)\)`)
+
+// SecretError 配置文件中的加密值无法解密
+type SecretError struct {
+	File string // 配置文件的路径
+	Key  string // 配置项的路径
+	Err  error
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("%s: decrypt %s error: %v", e.File, e.Key, e.Err)
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// SecretKeySource 查找配置密钥的来源, 优先使用环境变量GOWEB_CONFIG_KEY
+// 其次使用GOWEB_CONFIG_KEY_FILE指定的文件, 最后使用配置文件根目录下的secret.key
+// 返回密钥的来源, 环境变量的时候file为空
+func SecretKeySource(configFolder string, envMaps map[string]string) (key string, file string) {
+	if key := envMaps[SecretKeyEnv]; key != "" {
+		return key, ""
+	}
+	file = envMaps[SecretKeyFileEnv]
+	if file == "" {
+		file = filepath.Join(configFolder, SecretKeyFile)
+	}
+	if !util.Exists(file) {
+		return "", file
+	}
+	bf, err := os.ReadFile(file)
+	if err != nil {
+		return "", file
+	}
+	return strings.TrimSpace(string(bf)), file
+}
+
+// LoadSecretKey 读取配置密钥, 没有配置密钥的时候返回nil
+func LoadSecretKey(configFolder string, envMaps map[string]string) ([]byte, error) {
+	encoded, file := SecretKeySource(configFolder, envMaps)
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		if file == "" {
+			return nil, errors.New("env " + SecretKeyEnv + " should be a base64 encoded 32 bytes key")
+		}
+		return nil, errors.New("key file " + file + " should contain a base64 encoded 32 bytes key")
+	}
+	return key, nil
+}
+
+// GenerateSecretKey 生成一个新的配置密钥, 返回base64编码之后的值
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted 判断一个值是否为加密值enc(...)
+func IsEncrypted(value string) bool {
+	match := secretPattern.FindStringIndex(value)
+	return match != nil && match[0] == 0 && match[1] == len(value)
+}
+
+// Encrypt 使用AES-256-GCM加密, 返回enc(base64密文)格式的值, 可以直接写在配置文件中
+func Encrypt(key []byte, plain string) (string, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return "enc(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// Decrypt 解密enc(base64密文)格式的值
+func Decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value should be enc(...)")
+	}
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len("enc(") : len(value)-1])
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("cipher text too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cipher text can not be decrypted by the key")
+	}
+	return string(plain), nil
+}
+
+// ReplaceSecrets 将文本中所有的加密值使用fn替换, 用于更换密钥的时候重新加密配置文件
+func ReplaceSecrets(content []byte, fn func(value string) (string, error)) ([]byte, error) {
+	var err error
+	ret := secretPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		if err != nil {
+			return match
+		}
+		var value string
+		value, err = fn(string(match))
+		return []byte(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("config secret key not found, set env " + SecretKeyEnv + " or create " + SecretKeyFile)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptSecrets 解密配置结构中所有的加密值, 返回解密了的配置项路径, 列表中的值路径为下标, 例如: a.b.0
+func decryptSecrets(key []byte, prefix string, conf map[string]interface{}) ([]string, error) {
+	secrets := []string{}
+	for k, v := range conf {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		val, sub, err := decryptValue(key, path, v)
+		if err != nil {
+			return nil, err
+		}
+		conf[k] = val
+		secrets = append(secrets, sub...)
+	}
+	return secrets, nil
+}
+
+// decryptValue 解密一个配置值, map和列表中的值递归解密, 返回解密之后的值和解密了的配置项路径
+func decryptValue(key []byte, path string, v interface{}) (interface{}, []string, error) {
+	switch val := v.(type) {
+	case string:
+		if !IsEncrypted(val) {
+			return v, nil, nil
+		}
+		plain, err := Decrypt(key, val)
+		if err != nil {
+			return nil, nil, &SecretError{Key: path, Err: err}
+		}
+		return plain, []string{path}, nil
+	case []interface{}:
+		secrets := []string{}
+		for i, item := range val {
+			item, sub, err := decryptValue(key, path+"."+strconv.Itoa(i), item)
+			if err != nil {
+				return nil, nil, err
+			}
+			val[i] = item
+			secrets = append(secrets, sub...)
+		}
+		return val, secrets, nil
+	}
+	if m, ok := toStringMap(v); ok {
+		secrets, err := decryptSecrets(key, path, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, secrets, nil
+	}
+	return v, nil, nil
+}
+
+// RotateSecrets 使用新的密钥重新加密配置文件夹下所有配置文件中的加密值, 返回修改了的配置文件
+// 所有的加密值都解密成功之后, 先调用saveKey保存新的密钥, 再写入文件, 避免配置文件使用了没有保存的密钥加密
+// saveKey返回错误的时候不修改任何文件
+func RotateSecrets(configFolder string, oldKey []byte, newKey []byte, saveKey func() error) ([]string, error) {
+	contents := map[string][]byte{}
+	files := []string{}
+	err := filepath.Walk(configFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if _, _, ok := parseConfigFileName(info.Name()); !ok {
+			return nil
+		}
+		bf, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !secretPattern.Match(bf) {
+			return nil
+		}
+		content, err := ReplaceSecrets(bf, func(value string) (string, error) {
+			plain, err := Decrypt(oldKey, value)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", path, err)
+			}
+			return Encrypt(newKey, plain)
+		})
+		if err != nil {
+			return err
+		}
+		contents[path] = content
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if saveKey != nil {
+		if err := saveKey(); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("new key saved, but re-encrypt %s error: %w", file, err)
+		}
+		if err := os.WriteFile(file, contents[file], info.Mode()); err != nil {
+			return nil, fmt.Errorf("new key saved, but re-encrypt %s error: %w", file, err)
+		}
+	}
+	return files, nil
+}
+
+// SaveSecretKey 将base64编码的密钥写入密钥文件, 原来的密钥备份到file.bak, 返回备份文件, 原来没有密钥文件的时候为空
+// 先写入临时文件再重命名, 写入失败的时候原来的密钥文件不变
+func SaveSecretKey(file string, encoded string) (string, error) {
+	backup := ""
+	if old, err := os.ReadFile(file); err == nil {
+		backup = file + ".bak"
+		if err := os.WriteFile(backup, old, 0600); err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, []byte(encoded+"\n"), 0600); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return backup, nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSecretKey(t *testing.T) (string, []byte) {
+	encoded, err := GenerateSecretKey()
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	return encoded, key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	_, key := newTestSecretKey(t)
+	_, otherKey := newTestSecretKey(t)
+
+	value, err := Encrypt(key, "p@ss:word")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(value))
+	assert.False(t, IsEncrypted("x"+value))
+
+	plain, err := Decrypt(key, value)
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss:word", plain)
+
+	_, err = Decrypt(otherKey, value)
+	assert.Error(t, err)
+	_, err = Decrypt(nil, value)
+	assert.Error(t, err)
+}
+
+func TestConfig_SecretsInList(t *testing.T) {
+	encoded, key := newTestSecretKey(t)
+	dsn, err := Encrypt(key, "root:123@tcp(db1)/app")
+	require.NoError(t, err)
+	password, err := Encrypt(key, "456")
+	require.NoError(t, err)
+
+	base := t.TempDir()
+	content := "dsns:\n  - plain\n  - " + dsn + "\nnodes:\n  - host: a\n    password: " + password + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(base, "database.yaml"), []byte(content), 0644))
+
+	ins, err := NewConfig(nil, base, "development", map[string]string{SecretKeyEnv: encoded})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	// 列表中的加密值也会解密, 路径使用下标
+	assert.Equal(t, []string{"plain", "root:123@tcp(db1)/app"}, conf.GetStringSlice("database.dsns"))
+	assert.Equal(t, "root:123@tcp(db1)/app", conf.GetString("database.dsns.1"))
+	assert.Equal(t, "456", conf.GetString("database.nodes.0.password"))
+	assert.True(t, conf.IsSecret("database.dsns.1"))
+	assert.False(t, conf.IsSecret("database.dsns.0"))
+	assert.True(t, conf.IsSecret("database.nodes.0.password"))
+	assert.False(t, conf.IsSecret("database.nodes.0.host"))
+
+	// 列表中的加密值解密失败的时候返回所在的路径
+	_, otherKey := newTestSecretKey(t)
+	_, err = NewConfig(nil, base, "development", map[string]string{SecretKeyEnv: base64.StdEncoding.EncodeToString(otherKey)})
+	var secretErr *SecretError
+	require.True(t, errors.As(err, &secretErr), "%v", err)
+	assert.Equal(t, "database.dsns.1", secretErr.Key)
+}
+
+func TestConfig_Secrets(t *testing.T) {
+	encoded, key := newTestSecretKey(t)
+	password, err := Encrypt(key, "123456")
+	require.NoError(t, err)
+
+	base := t.TempDir()
+	file := filepath.Join(base, "database.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("mysql:\n  username: root\n  password: "+password+"\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "testing"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(base, "testing", "database.yaml"), []byte("mysql:\n  password: plain\n"), 0644))
+
+	// 没有密钥的时候启动失败
+	_, err = NewConfig(nil, base, "development", map[string]string{})
+	var secretErr *SecretError
+	require.True(t, errors.As(err, &secretErr), "%v", err)
+	assert.Equal(t, file, secretErr.File)
+	assert.Equal(t, "database.mysql.password", secretErr.Key)
+
+	envs := map[string]string{SecretKeyEnv: encoded}
+	ins, err := NewConfig(nil, base, "development", envs)
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	assert.Equal(t, "123456", conf.GetString("database.mysql.password"))
+	assert.True(t, conf.IsSecret("database.mysql.password"))
+	assert.False(t, conf.IsSecret("database.mysql.username"))
+	require.NoError(t, conf.Shutdown(context.Background()))
+
+	// 高优先级的配置层使用明文覆盖之后不再是加密的配置项
+	ins, err = NewConfig(nil, base, "testing", envs)
+	require.NoError(t, err)
+	conf = ins.(*Config)
+	assert.Equal(t, "plain", conf.GetString("database.mysql.password"))
+	assert.False(t, conf.IsSecret("database.mysql.password"))
+	require.NoError(t, conf.Shutdown(context.Background()))
+
+	// 更换密钥
+	newEncoded, newKey := newTestSecretKey(t)
+	// 保存密钥失败的时候不修改配置文件
+	before, err := os.ReadFile(file)
+	require.NoError(t, err)
+	_, err = RotateSecrets(base, key, newKey, func() error { return errors.New("save key error") })
+	assert.EqualError(t, err, "save key error")
+	after, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	saved := false
+	files, err := RotateSecrets(base, key, newKey, func() error {
+		saved = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, []string{file}, files)
+
+	ins, err = NewConfig(nil, base, "development", map[string]string{SecretKeyEnv: newEncoded})
+	require.NoError(t, err)
+	conf = ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	assert.Equal(t, "123456", conf.GetString("database.mysql.password"))
+
+	// 旧的密钥解密失败, 不会保存新的密钥
+	_, err = RotateSecrets(base, key, newKey, func() error {
+		t.Fatal("save key after decrypt error")
+		return nil
+	})
+	assert.Error(t, err)
+}
+
+func TestSaveSecretKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), SecretKeyFile)
+
+	backup, err := SaveSecretKey(file, "old")
+	require.NoError(t, err)
+	assert.Empty(t, backup)
+
+	backup, err = SaveSecretKey(file, "new")
+	require.NoError(t, err)
+	assert.Equal(t, file+".bak", backup)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(content))
+	content, err = os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(content))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.NoFileExists(t, file+".tmp")
+}
@@ -1,15 +1,18 @@
 package config
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"goweb/framework"
 	"goweb/framework/contract"
+	"goweb/framework/util"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,17 +20,34 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cast"
-	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	c framework.Container
-	folder string
+	folder string // 配置文件根目录
 	KeyDelim string // 路径的分隔符，默认为点
 	lock sync.RWMutex
 	envMaps map[string]string // 所有的环境变量
-	confMaps map[string]interface{} // 配置文件结构，key为文件名
-	confRaws map[string][]byte // 配置文件的原始信息
+	secretKey []byte // 解密配置文件中enc(...)的密钥, 没有配置的时候为nil
+	layers []*configLayer // 配置层, 按照优先级从低到高排列
+	confMaps map[string]interface{} // 所有配置层合并之后的配置文件结构，key为文件名
+
+	runtimeFolder string // 运行时目录, 远程配置的本地缓存保存在这个目录下
+	remoteStop chan struct{} // 关闭的时候停止轮询远程配置源
+	remoteWg sync.WaitGroup // 等待轮询远程配置源的goroutine结束
+
+	watcher *fsnotify.Watcher // 监控配置文件夹
+	watchDone chan struct{} // 监控的goroutine结束的时候关闭
+
+	subLock sync.Mutex // 保护subscribers
+	subscribers []configSubscriber // 通过Watch监听属性变化的回调
+	notifyLock sync.Mutex // 文件监控和远程配置轮询的goroutine都会重新加载配置, 保证每次重新加载和通知完成之后才开始下一次
+}
+
+// configSubscriber 监听某个属性变化的回调
+type configSubscriber struct {
+	key string
+	fn func(old, new interface{})
 }
 
 // 读取某个配置文件
@@ -35,100 +55,218 @@ func (c *Config) loadConfigFile(folder string, file string) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	//  判断文件是否以yaml或者yml作为后缀
-	s := strings.Split(file,".")
-	if len(s) == 2 && (s[1] == "yml" || s[1] == "yaml" ){
-		name := s[0]
-		// 读取文件内容
-		bf, err := os.ReadFile(filepath.Join(folder,file))
-		if err != nil {
-			return err
-		}
-		// 直接针对文本做环境变量的替换
-		bf = replace(bf, c.envMaps)
-		// 解析对应的文件
-		conf := map[string]interface{}{}
-		if err := yaml.Unmarshal(bf, &conf);err != nil {
-			return err
-		}
-		c.confMaps[name] = conf
-		c.confRaws[name] = bf
-
-		// 读取app.path中的信息，更新app对应的folder
-		if name == "app" && c.c.IsBind(contract.AppKey) {
-			if p, ok := conf["path"]; ok {
-				appService := c.c.MustMake(contract.AppKey).(contract.App)
-				appService.LoadAppConfig(cast.ToStringMapString(p))
-			}
-		}
+	layer := c.findLayer(folder)
+	if layer == nil {
+		return nil
 	}
+
+	// 根据后缀找到对应的解析方法, 没有注册解析方法的文件直接忽略
+	name, decoder, ok := parseConfigFileName(file)
+	if !ok {
+		return nil
+	}
+	// 读取文件内容
+	bf, err := os.ReadFile(filepath.Join(folder,file))
+	if err != nil {
+		return err
+	}
+	conf, raw, secrets, err := c.parseConfig(filepath.Join(folder, file), name, decoder, bf)
+	if err != nil {
+		return err
+	}
+	layer.maps[name] = conf
+	layer.raws[name] = raw
+	layer.files[name] = filepath.Join(folder, file)
+	layer.secrets[name] = secrets
+	c.mergeLayers()
 	return nil
 }
 
 
+// parseConfig 解析配置的内容, source为配置文件的路径或者远程配置的地址
+// 依次做环境变量的替换, 解析, 解密, 返回配置结构, 替换之后的内容和解密了的配置项
+func (c *Config) parseConfig(source string, name string, decoder Decoder, bf []byte) (map[string]interface{}, []byte, []string, error) {
+	// 直接针对文本做环境变量的替换, 缺少必须的环境变量的时候返回文件和行号
+	bf, err := replace(bf, c.envMaps)
+	if err != nil {
+		if interpolateErr, ok := err.(*InterpolateError); ok {
+			interpolateErr.File = source
+		}
+		return nil, nil, nil, err
+	}
+	// 解析对应的文件
+	conf, err := decoder(bf)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("parse config file %s error: %w", source, err)
+	}
+	// 解密配置中的加密值enc(...), 记录解密的配置项, 打印的时候隐藏
+	secrets, err := decryptSecrets(c.secretKey, name, conf)
+	if err != nil {
+		if secretErr, ok := err.(*SecretError); ok {
+			secretErr.File = source
+		}
+		return nil, nil, nil, err
+	}
+	return conf, bf, secrets, nil
+}
+
 // 删除文件的操作
 func (c *Config) removeConfigFile(folder string, file string) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	s := strings.Split(file,".")
-	// 只有yaml或者yml后缀才执行
-	if len(s) == 2 && (s[1] == "yml" || s[1] == "yaml"){
-		name := s[0]
-		// 删除内存中对应的key
-		delete(c.confRaws,name)
-		delete(c.confMaps,name)
+
+	layer := c.findLayer(folder)
+	if layer == nil {
+		return nil
 	}
+	name, _, ok := parseConfigFileName(file)
+	if !ok {
+		return nil
+	}
+	// 删除内存中对应的key, 同名不同后缀的文件被删除的时候不影响当前的配置
+	if layer.files[name] != filepath.Join(folder, file) {
+		return nil
+	}
+	delete(layer.raws,name)
+	delete(layer.maps,name)
+	delete(layer.files,name)
+	delete(layer.secrets,name)
+	c.mergeLayers()
 	return nil
 }
 
+// findLayer 查找文件夹对应的配置层, 调用的时候需要持有锁
+func (c *Config) findLayer(folder string) *configLayer {
+	folder, _ = filepath.Abs(folder)
+	for _, layer := range c.layers {
+		if layer.folder != "" && layer.folder == folder {
+			return layer
+		}
+	}
+	return nil
+}
+
+// mergeLayers 按照优先级从低到高合并所有的配置层, 调用的时候需要持有锁
+func (c *Config) mergeLayers() {
+	confMaps := map[string]interface{}{}
+	for _, layer := range c.layers {
+		layer.merge()
+		for name, conf := range layer.conf {
+			confMaps[name] = mergeMap(confMaps[name], conf)
+		}
+	}
+	c.confMaps = confMaps
+}
+
+// loadAppPath 读取app.path中的信息，更新app对应的folder
+func (c *Config) loadAppPath() {
+	if c.c == nil || !c.c.IsBind(contract.AppKey) {
+		return
+	}
+	if p := c.find("app.path"); p != nil {
+		appService := framework.MustMake[contract.App](c.c)
+		appService.LoadAppConfig(cast.ToStringMapString(p))
+	}
+}
+
 
 // NewConfig 初始化Config方法
+// 参数为: 容器, 配置文件根目录, 当前环境, 环境变量, 运行时目录(可选), 容器可以为nil, 比如只用于校验某个环境的配置
+// 配置按照优先级从低到高分为: 默认值, 根目录config/, 环境目录config/{env}/, 远程配置源, 本地目录config/{env}.local/
 func NewConfig(params ...interface{}) (interface{}, error) {
-	container := params[0].(framework.Container)
-	envFolder := params[1].(string)
-	envMaps := params[2].(map[string]string)
+	container, _ := params[0].(framework.Container)
+	configFolder := params[1].(string)
+	env := params[2].(string)
+	envMaps := params[3].(map[string]string)
+	runtimeFolder := ""
+	if len(params) > 4 {
+		runtimeFolder, _ = params[4].(string)
+	}
 
 	// 检查文件夹是否存在
-	if _, err := os.Stat(envFolder);os.IsNotExist(err) {
-		return nil, errors.New("folder " + envFolder + " not exist: " + err.Error())
+	if _, err := os.Stat(configFolder);os.IsNotExist(err) {
+		return nil, errors.New("folder " + configFolder + " not exist: " + err.Error())
+	}
+	// 读取解密配置的密钥
+	secretKey, err := LoadSecretKey(configFolder, envMaps)
+	if err != nil {
+		return nil, err
 	}
 	// 实例化
 	conf := &Config{
 		c: container,
 		envMaps: envMaps,
-		folder: envFolder,
+		secretKey: secretKey,
+		runtimeFolder: runtimeFolder,
+		folder: configFolder,
+		layers: []*configLayer{
+			newConfigLayer(contract.ConfigLayerDefault, ""),
+			newConfigLayer(contract.ConfigLayerBase, configFolder),
+			newConfigLayer(contract.ConfigLayerEnv, filepath.Join(configFolder, env)),
+			newConfigLayer(contract.ConfigLayerRemote, ""),
+			newConfigLayer(contract.ConfigLayerLocal, filepath.Join(configFolder, env+".local")),
+		},
 		confMaps: map[string]interface{}{},
-		confRaws: map[string][]byte{},
 		KeyDelim: ".",
 		lock: sync.RWMutex{},
 	}
 
-	// 读取每个文件
-	files, err := ioutil.ReadDir(envFolder)
+	// 监控文件夹文件
+	watch, err := fsnotify.NewWatcher()
 	if err != nil {
-		return nil, err
+		return nil,err
 	}
 
-	for _, file := range files {
-		fileName := file.Name()
-		err := conf.loadConfigFile(envFolder, fileName)
-		if err != nil {
-			log.Println(err)
+	// 读取每个配置层的文件, 不存在的配置层文件夹直接跳过
+	for _, layer := range conf.layers {
+		if layer.folder == "" || !util.Exists(layer.folder) {
 			continue
 		}
+		files, err := ioutil.ReadDir(layer.folder)
+		if err != nil {
+			watch.Close()
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			fileName := file.Name()
+			err := conf.loadConfigFile(layer.folder, fileName)
+			if err != nil {
+				// 缺少必须的环境变量, 或者加密值无法解密的时候直接启动失败
+				var interpolateErr *InterpolateError
+				var secretErr *SecretError
+				if errors.As(err, &interpolateErr) || errors.As(err, &secretErr) {
+					watch.Close()
+					return nil, err
+				}
+				log.Println(err)
+				continue
+			}
+		}
+		if err := watch.Add(layer.folder); err != nil {
+			watch.Close()
+			return nil,err
+		}
 	}
-	// 监控文件夹文件
-	watch, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil,err
+	// 加载remote.sources中配置的远程配置源
+	if err := conf.startRemote(); err != nil {
+		watch.Close()
+		return nil, err
 	}
-
-	err = watch.Add(envFolder)
-	if err != nil {
-		return nil,err
+	conf.loadAppPath()
+	// 启动的时候按照注册的结构校验配置, 不符合的配置项打印出来, 只用于校验的时候由调用方处理
+	if container != nil {
+		for _, violation := range conf.Validate() {
+			log.Println("config invalid:", violation.String())
+		}
 	}
+	conf.watcher = watch
+	conf.watchDone = make(chan struct{})
 
 	go func() {
+		defer close(conf.watchDone)
 		defer func ()  {
 			if err := recover();err != nil {
 				fmt.Println(err)
@@ -137,8 +275,12 @@ func NewConfig(params ...interface{}) (interface{}, error) {
 
 		for {
 			select{
-			case ev := <- watch.Events:
+			case ev, ok := <- watch.Events:
 				{
+					// watcher已经关闭
+					if !ok {
+						return
+					}
 					//判断事件发生的类型
 					// Create 创建
 					// Write 写入
@@ -147,22 +289,38 @@ func NewConfig(params ...interface{}) (interface{}, error) {
 					index := strings.LastIndex(path, string(os.PathSeparator)) 	
 					folder := path[:index]
 					fileName := path[index+1:]
+					// 只处理注册了解析方法的配置文件
+					if _, _, ok := parseConfigFileName(fileName); !ok {
+						continue
+					}
 					if ev.Op&fsnotify.Create == fsnotify.Create {
 						log.Println("创建文件 : ", ev.Name)
-						conf.loadConfigFile(folder, fileName)
+						conf.notify(func() {
+							// 加载失败的时候保留之前的配置
+							if err := conf.loadConfigFile(folder, fileName); err != nil {
+								log.Println(err)
+							}
+						})
 					}
 					if ev.Op&fsnotify.Write == fsnotify.Write {
 						log.Println("写入文件 : ", ev.Name)
-						conf.loadConfigFile(folder, fileName)
+						conf.notify(func() {
+							// 加载失败的时候保留之前的配置
+							if err := conf.loadConfigFile(folder, fileName); err != nil {
+								log.Println(err)
+							}
+						})
 					}
-					if ev.Op&fsnotify.Remove == fsnotify.Remove {
+					if ev.Op&fsnotify.Remove == fsnotify.Remove || ev.Op&fsnotify.Rename == fsnotify.Rename {
 						log.Println("删除文件 : ", ev.Name)
-						conf.removeConfigFile(folder, fileName)
+						conf.notify(func() { conf.removeConfigFile(folder, fileName) })
 					}
 				}
-			case err := <- watch.Errors:
+			case err, ok := <- watch.Errors:
 				{
-					
+					if !ok {
+						return
+					}
 					log.Println("error : ", err)
 					return
 				}
@@ -173,18 +331,23 @@ func NewConfig(params ...interface{}) (interface{}, error) {
 	return conf, nil
 }
 
-
-// replace 表示使用环境变量maps替换context中的env(xxx)的环境变量
-func replace(content []byte, maps map[string]string) []byte {
-	if maps == nil {
-		return content
+// Shutdown 停止监控配置文件夹和轮询远程配置源, 等待对应的goroutine结束
+func (conf *Config) Shutdown(ctx context.Context) error {
+	if err := conf.stopRemote(ctx); err != nil {
+		return err
 	}
-	// 直接使用ReplaceAll替换。这个性能可能不是最优，但是配置文件加载，频率是比较低的，可以接受
-	for key, val := range maps {
-		reKey := "env(" + key + ")"
-		content = bytes.ReplaceAll(content,[]byte(reKey),[]byte(val))
+	if conf.watcher == nil {
+		return nil
+	}
+	if err := conf.watcher.Close(); err != nil {
+		return err
+	}
+	select {
+	case <-conf.watchDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return content
 }
 
 
@@ -207,6 +370,9 @@ func searchMap(source map[string]interface{}, path []string) interface{} {
 		case map[string]interface{}:
 			// 如果是map[string]，直接循环调用
 			return searchMap(next.(map[string]interface{}),path[1:])
+		case []interface{}:
+			// 如果是列表，下一个路径为下标
+			return searchSlice(next.([]interface{}), path[1:])
 		default:
 			return nil
 		}
@@ -214,6 +380,26 @@ func searchMap(source map[string]interface{}, path []string) interface{} {
 	return nil
 }
 
+// searchSlice 在列表中查找某个下标开始的路径, 例如: hosts.0
+func searchSlice(source []interface{}, path []string) interface{} {
+	index, err := strconv.Atoi(path[0])
+	if err != nil || index < 0 || index >= len(source) {
+		return nil
+	}
+	if len(path) == 1 {
+		return source[index]
+	}
+	switch next := source[index].(type) {
+	case map[interface{}]interface{}:
+		return searchMap(cast.ToStringMap(next), path[1:])
+	case map[string]interface{}:
+		return searchMap(next, path[1:])
+	case []interface{}:
+		return searchSlice(next, path[1:])
+	}
+	return nil
+}
+
 // 通过path来获取某个配置项
 func (conf *Config) find(key string) interface{} {
 	conf.lock.RLock()
@@ -292,4 +478,49 @@ func (conf *Config) Load(key string, val interface{}) error {
 	}
 
 	return decoder.Decode(conf.find(key))
-}
\ No newline at end of file
+}
+
+// Watch 监听某个属性的变化, 只有属性的值真正发生变化的时候才会调用fn, fn中不能调用Set
+func (conf *Config) Watch(key string, fn func(old, new interface{})) {
+	conf.subLock.Lock()
+	defer conf.subLock.Unlock()
+	conf.subscribers = append(conf.subscribers, configSubscriber{key: key, fn: fn})
+}
+
+// notify 执行reload重新加载配置, 然后通知值发生了变化的监听者
+// 多次重新加载串行执行, 监听者收到的old一定是上一次通知的new, 所以监听者中不能再调用Set
+func (conf *Config) notify(reload func()) {
+	conf.notifyLock.Lock()
+	defer conf.notifyLock.Unlock()
+
+	conf.subLock.Lock()
+	subscribers := append([]configSubscriber{}, conf.subscribers...)
+	conf.subLock.Unlock()
+
+	olds := make([]interface{}, len(subscribers))
+	for i, sub := range subscribers {
+		olds[i] = conf.find(sub.key)
+	}
+	oldPath := conf.find("app.path")
+	reload()
+	if !reflect.DeepEqual(oldPath, conf.find("app.path")) {
+		conf.loadAppPath()
+	}
+	for i, sub := range subscribers {
+		value := conf.find(sub.key)
+		if reflect.DeepEqual(olds[i], value) {
+			continue
+		}
+		conf.callSubscriber(sub, olds[i], value)
+	}
+}
+
+// callSubscriber 调用监听者, 监听者panic的时候不影响配置文件的监控
+func (conf *Config) callSubscriber(sub configSubscriber, old, new interface{}) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("config watch", sub.key, "panic:", err)
+		}
+	}()
+	sub.fn(old, new)
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configChange struct {
+	key      string
+	old, new interface{}
+}
+
+func TestConfig_Watch(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "testing")
+	require.NoError(t, os.MkdirAll(folder, os.ModePerm))
+	file := filepath.Join(folder, "log.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("level: info\nformatter: text\n"), 0644))
+
+	ins, err := NewConfig(framework.NewContainer(), filepath.Dir(folder), "testing", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+
+	changes := make(chan configChange, 10)
+	for _, key := range []string{"log.level", "log.formatter", "log.folder"} {
+		key := key
+		conf.Watch(key, func(old, new interface{}) {
+			changes <- configChange{key, old, new}
+		})
+	}
+
+	// 只有值发生变化的属性才会通知, 使用重命名替换文件, 防止读取到写了一半的文件
+	tmp := filepath.Join(t.TempDir(), "log.yaml")
+	require.NoError(t, os.WriteFile(tmp, []byte("level: debug\nformatter: text\n"), 0644))
+	require.NoError(t, os.Rename(tmp, file))
+	select {
+	case change := <-changes:
+		assert.Equal(t, configChange{"log.level", "info", "debug"}, change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait config change timeout")
+	}
+
+	// 停止监控文件夹, 之后直接重新加载配置文件
+	require.NoError(t, conf.Shutdown(context.Background()))
+	assert.Empty(t, changes)
+
+	// 新增和删除的属性, 不存在的值为nil
+	require.NoError(t, os.WriteFile(file, []byte("level: debug\nformatter: text\nfolder: ./log\n"), 0644))
+	conf.notify(func() { conf.loadConfigFile(folder, "log.yaml") })
+	assert.Equal(t, configChange{"log.folder", nil, "./log"}, <-changes)
+	conf.notify(func() { conf.removeConfigFile(folder, "log.yaml") })
+	assert.ElementsMatch(t, []configChange{
+		{"log.level", "debug", nil},
+		{"log.formatter", "text", nil},
+		{"log.folder", "./log", nil},
+	}, []configChange{<-changes, <-changes, <-changes})
+	assert.Empty(t, changes)
+}
+
+func TestConfig_WatchConcurrentReload(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "testing")
+	require.NoError(t, os.MkdirAll(folder, os.ModePerm))
+	file := filepath.Join(folder, "log.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("level: 0\n"), 0644))
+
+	ins, err := NewConfig(framework.NewContainer(), filepath.Dir(folder), "testing", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	require.NoError(t, conf.Shutdown(context.Background()))
+
+	var lock sync.Mutex
+	changes := []configChange{}
+	conf.Watch("log.level", func(old, new interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		changes = append(changes, configChange{"log.level", old, new})
+	})
+
+	// 文件监控和远程配置轮询同时重新加载配置, 每次通知的old都是上一次通知的new
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conf.notify(func() {
+				os.WriteFile(file, []byte("level: "+strconv.Itoa(i)+"\n"), 0644)
+				conf.loadConfigFile(folder, "log.yaml")
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, changes, 20)
+	last := interface{}(0)
+	for _, change := range changes {
+		assert.Equal(t, last, change.old)
+		last = change.new
+	}
+	assert.Equal(t, last, conf.Get("log.level"))
+}
+
+func TestConfig_Layers(t *testing.T) {
+	base := t.TempDir()
+	writeFile := func(folder, file, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(base, folder), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(base, folder, file), []byte(content), 0644))
+	}
+	writeFile("", "app.yaml", "name: base\nurl: http://base\ndev:\n  port: 8070\n  backend:\n    port: 8072\n")
+	writeFile("", "database.yaml", "driver: mysql\n")
+	writeFile("testing", "app.yaml", "url: http://testing\ndev:\n  backend:\n    port: 9072\n")
+	writeFile("testing.local", "app.yaml", "dev:\n  port: 9070\n")
+	// 其他环境的配置不会被读取
+	writeFile("production", "app.yaml", "name: production\n")
+
+	ins, err := NewConfig(framework.NewContainer(), base, "testing", map[string]string{})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+
+	conf.SetDefault("app.name", "default")
+	conf.SetDefault("app.timeout", 3)
+	conf.SetDefault("log.level", "info")
+
+	assert.Equal(t, "base", conf.GetString("app.name"))
+	assert.Equal(t, "http://testing", conf.GetString("app.url"))
+	assert.Equal(t, 9070, conf.GetInt("app.dev.port"))
+	assert.Equal(t, 9072, conf.GetInt("app.dev.backend.port"))
+	assert.Equal(t, 3, conf.GetInt("app.timeout"))
+	assert.Equal(t, "info", conf.GetString("log.level"))
+	assert.Equal(t, "mysql", conf.GetString("database.driver"))
+
+	sources := conf.Explain("app.name")
+	require.Len(t, sources, 2)
+	assert.Equal(t, contract.ConfigSource{Layer: contract.ConfigLayerBase, File: filepath.Join(base, "app.yaml"), Value: "base"}, sources[0])
+	assert.Equal(t, contract.ConfigSource{Layer: contract.ConfigLayerDefault, Value: "default"}, sources[1])
+
+	sources = conf.Explain("app.dev.port")
+	require.Len(t, sources, 2)
+	assert.Equal(t, contract.ConfigLayerLocal, sources[0].Layer)
+	assert.Equal(t, filepath.Join(base, "testing.local", "app.yaml"), sources[0].File)
+	assert.Equal(t, contract.ConfigLayerBase, sources[1].Layer)
+	assert.Empty(t, conf.Explain("app.not_exist"))
+}
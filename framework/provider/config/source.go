@@ -0,0 +1,307 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goweb/framework/contract"
+	"goweb/framework/util"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// Source 远程配置源, 比如http接口, key/value存储
+type Source interface {
+	// Name 配置源的名称, 同时作为本地缓存的目录名
+	Name() string
+	// Fetch 获取配置源中所有的配置文件, key为文件名, 例如: app.yaml, 使用文件的后缀选择解析方法
+	// version为上一次成功加载的配置的版本, 比如http接口的ETag, 还没有成功加载过的时候为空
+	// 配置没有变化的时候返回changed为false, 比如http接口返回304
+	// 返回的newVersion只有在配置加载成功之后才会保存, 加载失败的时候下一次仍然使用之前的version获取
+	Fetch(ctx context.Context, version string) (files map[string][]byte, newVersion string, changed bool, err error)
+}
+
+// SourceDriver 根据配置创建配置源, options为remote.sources中的一项配置
+type SourceDriver func(options map[string]interface{}) (Source, error)
+
+// sourceDrivers 配置源的驱动, key为remote.sources中的driver
+var sourceDrivers = map[string]SourceDriver{
+	"http": newHttpSource,
+}
+var sourceDriversLock sync.RWMutex
+
+// RegisterSourceDriver 注册一个配置源的驱动, 比如 RegisterSourceDriver("etcd", newEtcdSource)
+// 需要在配置服务实例化之前注册, 一般在init中调用
+func RegisterSourceDriver(driver string, fn SourceDriver) {
+	sourceDriversLock.Lock()
+	defer sourceDriversLock.Unlock()
+	sourceDrivers[driver] = fn
+}
+
+func getSourceDriver(driver string) (SourceDriver, bool) {
+	sourceDriversLock.RLock()
+	defer sourceDriversLock.RUnlock()
+	fn, ok := sourceDrivers[driver]
+	return fn, ok
+}
+
+const (
+	// defaultSourceInterval 配置源默认的轮询间隔
+	defaultSourceInterval = 30 * time.Second
+	// defaultSourceTimeout 配置源默认的超时时间
+	defaultSourceTimeout = 5 * time.Second
+	// sourceCacheFolder 配置源的本地缓存目录, 在RuntimeFolder下
+	sourceCacheFolder = "config_cache"
+)
+
+// remoteSource 一个正在使用的配置源
+type remoteSource struct {
+	source   Source
+	interval time.Duration
+	timeout  time.Duration
+	names    []string // 这个配置源提供的配置名称
+	version  string   // 上一次成功加载的配置的版本
+}
+
+// startRemote 读取remote.sources中配置的配置源, 第一次获取失败的时候使用本地缓存
+// 之后按照轮询间隔获取, 配置有变化的时候和配置文件的变化一样重新加载并且通知
+func (conf *Config) startRemote() error {
+	sources := []*remoteSource{}
+	for i, item := range cast.ToSlice(conf.Get("remote.sources")) {
+		options, ok := toStringMap(item)
+		if !ok {
+			return fmt.Errorf("remote.sources[%d] should be a map", i)
+		}
+		driver := cast.ToString(options["driver"])
+		fn, ok := getSourceDriver(driver)
+		if !ok {
+			return fmt.Errorf("remote.sources[%d]: driver %q not registered", i, driver)
+		}
+		source, err := fn(options)
+		if err != nil {
+			return fmt.Errorf("remote.sources[%d]: %w", i, err)
+		}
+		remote := &remoteSource{source: source, interval: defaultSourceInterval, timeout: defaultSourceTimeout}
+		if val, ok := options["interval"]; ok {
+			if remote.interval, err = cast.ToDurationE(val); err != nil || remote.interval <= 0 {
+				return fmt.Errorf("remote.sources[%d]: invalid interval %v", i, val)
+			}
+		}
+		if val, ok := options["timeout"]; ok {
+			if remote.timeout, err = cast.ToDurationE(val); err != nil || remote.timeout <= 0 {
+				return fmt.Errorf("remote.sources[%d]: invalid timeout %v", i, val)
+			}
+		}
+		sources = append(sources, remote)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	for _, remote := range sources {
+		if err := conf.fetchRemote(remote, true); err != nil {
+			// 获取失败的时候使用上一次成功获取的缓存, 保证离线也能启动
+			log.Println("fetch remote config", remote.source.Name(), "error:", err)
+			files, cacheErr := conf.readRemoteCache(remote.source.Name())
+			if cacheErr != nil {
+				log.Println("read remote config cache", remote.source.Name(), "error:", cacheErr)
+				continue
+			}
+			if err := conf.applyRemote(remote, files); err != nil {
+				return err
+			}
+		}
+	}
+
+	conf.remoteStop = make(chan struct{})
+	for _, remote := range sources {
+		conf.remoteWg.Add(1)
+		go conf.pollRemote(remote)
+	}
+	return nil
+}
+
+// pollRemote 按照轮询间隔获取配置源
+func (conf *Config) pollRemote(remote *remoteSource) {
+	defer conf.remoteWg.Done()
+	ticker := time.NewTicker(remote.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-conf.remoteStop:
+			return
+		case <-ticker.C:
+			if err := conf.fetchRemote(remote, false); err != nil {
+				// 获取失败的时候保留之前的配置
+				log.Println("fetch remote config", remote.source.Name(), "error:", err)
+			}
+		}
+	}
+}
+
+// fetchRemote 获取一次配置源, 有变化的时候重新加载配置, 并且更新本地缓存
+func (conf *Config) fetchRemote(remote *remoteSource, boot bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remote.timeout)
+	defer cancel()
+	files, version, changed, err := remote.source.Fetch(ctx, remote.version)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	if boot {
+		err = conf.applyRemote(remote, files)
+	} else {
+		conf.notify(func() { err = conf.applyRemote(remote, files) })
+	}
+	if err != nil {
+		return err
+	}
+	// 加载成功之后才保存版本, 否则配置源会认为配置没有变化, 之后不再返回修复后的配置
+	remote.version = version
+	return conf.writeRemoteCache(remote.source.Name(), files)
+}
+
+// applyRemote 使用配置源获取的配置文件替换这个配置源之前提供的配置
+// 任意一个配置文件解析失败的时候不修改当前的配置
+func (conf *Config) applyRemote(remote *remoteSource, files map[string][]byte) error {
+	type parsed struct {
+		conf    map[string]interface{}
+		raw     []byte
+		secrets []string
+	}
+	source := remote.source.Name()
+	results := map[string]parsed{}
+	for file, bf := range files {
+		name, decoder, ok := parseConfigFileName(file)
+		if !ok {
+			return errors.New("remote config " + source + ": unsupported config file " + file)
+		}
+		c, raw, secrets, err := conf.parseConfig(source+"/"+file, name, decoder, bf)
+		if err != nil {
+			return err
+		}
+		results[name] = parsed{conf: c, raw: raw, secrets: secrets}
+	}
+
+	conf.lock.Lock()
+	defer conf.lock.Unlock()
+	layer := conf.remoteLayer()
+	for _, name := range remote.names {
+		delete(layer.maps, name)
+		delete(layer.raws, name)
+		delete(layer.files, name)
+		delete(layer.secrets, name)
+	}
+	remote.names = remote.names[:0]
+	for name, result := range results {
+		layer.maps[name] = result.conf
+		layer.raws[name] = result.raw
+		layer.files[name] = source
+		layer.secrets[name] = result.secrets
+		remote.names = append(remote.names, name)
+	}
+	sort.Strings(remote.names)
+	conf.mergeLayers()
+	return nil
+}
+
+// remoteLayer 远程配置层, 调用的时候需要持有锁
+func (conf *Config) remoteLayer() *configLayer {
+	for _, layer := range conf.layers {
+		if layer.name == contract.ConfigLayerRemote {
+			return layer
+		}
+	}
+	return nil
+}
+
+// remoteCacheFolder 配置源的本地缓存目录
+func (conf *Config) remoteCacheFolder(source string) string {
+	// 配置源的名称可能是url, 替换掉不能作为目录名的字符
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '?' || r == '*' || r == '"' || r == '<' || r == '>' || r == '|' {
+			return '_'
+		}
+		return r
+	}, source)
+	return filepath.Join(conf.runtimeFolder, sourceCacheFolder, name)
+}
+
+// writeRemoteCache 保存最后一次成功获取的配置, 先写入临时目录再替换, 避免写入一半的缓存
+func (conf *Config) writeRemoteCache(source string, files map[string][]byte) error {
+	if conf.runtimeFolder == "" {
+		return nil
+	}
+	folder := conf.remoteCacheFolder(source)
+	tmp := folder + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0700); err != nil {
+		return err
+	}
+	for file, bf := range files {
+		if err := os.WriteFile(filepath.Join(tmp, filepath.Base(file)), bf, 0600); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(folder); err != nil {
+		return err
+	}
+	return os.Rename(tmp, folder)
+}
+
+// readRemoteCache 读取配置源的本地缓存
+func (conf *Config) readRemoteCache(source string) (map[string][]byte, error) {
+	if conf.runtimeFolder == "" {
+		return nil, errors.New("runtime folder not set")
+	}
+	folder := conf.remoteCacheFolder(source)
+	if !util.Exists(folder) {
+		return nil, errors.New("cache " + folder + " not exist")
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		bf, err := os.ReadFile(filepath.Join(folder, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = bf
+	}
+	return files, nil
+}
+
+// stopRemote 停止轮询配置源, 等待轮询的goroutine结束
+func (conf *Config) stopRemote(ctx context.Context) error {
+	if conf.remoteStop == nil {
+		return nil
+	}
+	close(conf.remoteStop)
+	done := make(chan struct{})
+	go func() {
+		conf.remoteWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
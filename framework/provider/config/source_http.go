@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// httpSource 通过http接口获取配置, 使用ETag判断配置是否有变化
+// remote.yaml中的配置示例:
+//
+//	sources:
+//	  - driver: http
+//	    url: http://config.example.com/goweb/app.yaml
+//	    file: app.yaml # 对应的配置文件名, 默认使用url中的文件名, 没有后缀的时候根据Content-Type判断格式
+//	    headers:
+//	      Authorization: Bearer ${CONFIG_TOKEN}
+type httpSource struct {
+	url     string
+	file    string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newHttpSource 根据配置创建http配置源
+func newHttpSource(options map[string]interface{}) (Source, error) {
+	rawURL := cast.ToString(options["url"])
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.New("http source url " + rawURL + " is invalid")
+	}
+	file := cast.ToString(options["file"])
+	if file == "" {
+		file = path.Base(u.Path)
+	}
+	// 没有后缀的时候根据返回的Content-Type判断格式
+	if _, _, ok := parseConfigFileName(file); !ok && (path.Ext(file) != "" || file == "" || file == "/") {
+		return nil, errors.New("http source file " + file + " should be a config file name like app.yaml")
+	}
+	return &httpSource{
+		url:     rawURL,
+		file:    file,
+		headers: cast.ToStringMapString(options["headers"]),
+		client:  &http.Client{},
+	}, nil
+}
+
+// Name 使用url作为配置源的名称
+func (s *httpSource) Name() string {
+	return s.url
+}
+
+// Fetch 获取配置, version为上一次成功加载的ETag, 返回304的时候表示没有变化
+func (s *httpSource) Fetch(ctx context.Context, version string) (map[string][]byte, string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, version, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("http source %s response status %s", s.url, resp.Status)
+	}
+	bf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return map[string][]byte{s.fileName(resp): bf}, resp.Header.Get("ETag"), true, nil
+}
+
+// fileName 配置文件名, 配置的文件名没有后缀的时候, 根据Content-Type判断json或者yaml
+func (s *httpSource) fileName(resp *http.Response) string {
+	if path.Ext(s.file) != "" {
+		return s.file
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if strings.HasSuffix(mediaType, "json") {
+		return s.file + ".json"
+	}
+	return s.file + ".yaml"
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"goweb/framework/contract"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfigServer 返回可以修改内容的配置接口, 使用内容的hash作为ETag
+type testConfigServer struct {
+	lock     sync.Mutex
+	content  string
+	requests int
+	notMod   int
+}
+
+func (s *testConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests++
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum([]byte(s.content)))
+	if r.Header.Get("If-None-Match") == etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(s.content))
+}
+
+func (s *testConfigServer) set(content string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.content = content
+}
+
+func TestConfig_RemoteSource(t *testing.T) {
+	handler := &testConfigServer{content: "url: http://remote\n"}
+	server := httptest.NewServer(handler)
+
+	base := t.TempDir()
+	runtime := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "app.yaml"), []byte("name: goweb\nurl: http://base\n"), 0644))
+	remote := "sources:\n  - driver: http\n    url: " + server.URL + "/goweb/app.yaml\n    interval: 20ms\n"
+	require.NoError(t, os.WriteFile(filepath.Join(base, "remote.yaml"), []byte(remote), 0644))
+
+	ins, err := NewConfig(nil, base, "testing", map[string]string{}, runtime)
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	assert.Equal(t, "http://remote", conf.GetString("app.url"))
+	assert.Equal(t, "goweb", conf.GetString("app.name"))
+	sources := conf.Explain("app.url")
+	require.Len(t, sources, 2)
+	assert.Equal(t, contract.ConfigLayerRemote, sources[0].Layer)
+	assert.Equal(t, server.URL+"/goweb/app.yaml", sources[0].File)
+	assert.Error(t, conf.Set("app.url", "x"))
+
+	// 配置变化的时候和配置文件一样通知
+	changed := make(chan interface{}, 1)
+	conf.Watch("app.url", func(old, new interface{}) { changed <- new })
+	time.Sleep(60 * time.Millisecond)
+	handler.set("url: http://changed\n")
+	select {
+	case val := <-changed:
+		assert.Equal(t, "http://changed", val)
+	case <-time.After(2 * time.Second):
+		t.Fatal("remote config change not notified")
+	}
+	require.NoError(t, conf.Shutdown(context.Background()))
+	handler.lock.Lock()
+	assert.Greater(t, handler.notMod, 0)
+	handler.lock.Unlock()
+
+	// 配置源不能访问的时候使用本地缓存
+	server.Close()
+	ins, err = NewConfig(nil, base, "testing", map[string]string{}, runtime)
+	require.NoError(t, err)
+	conf = ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	assert.Equal(t, "http://changed", conf.GetString("app.url"))
+}
+
+func TestConfig_RemoteSourceInvalid(t *testing.T) {
+	handler := &testConfigServer{content: "url: [invalid\n"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ins, err := NewConfig(nil, t.TempDir(), "testing", map[string]string{}, t.TempDir())
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+	source, err := newHttpSource(map[string]interface{}{"url": server.URL + "/app.yaml"})
+	require.NoError(t, err)
+	remote := &remoteSource{source: source, timeout: time.Second}
+
+	// 加载失败的时候不保存ETag, 之后仍然会获取完整的配置
+	assert.Error(t, conf.fetchRemote(remote, false))
+	assert.Error(t, conf.fetchRemote(remote, false))
+	assert.Empty(t, remote.version)
+	handler.lock.Lock()
+	assert.Equal(t, 2, handler.requests)
+	assert.Equal(t, 0, handler.notMod)
+	handler.lock.Unlock()
+
+	handler.set("url: http://fixed\n")
+	require.NoError(t, conf.fetchRemote(remote, false))
+	assert.Equal(t, "http://fixed", conf.GetString("app.url"))
+	assert.NotEmpty(t, remote.version)
+	require.NoError(t, conf.fetchRemote(remote, false))
+	handler.lock.Lock()
+	assert.Equal(t, 1, handler.notMod)
+	handler.lock.Unlock()
+}
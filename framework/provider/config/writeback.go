@@ -0,0 +1,208 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"goweb/framework/contract"
+	"goweb/framework/util"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Set 修改某个属性的值, 并且写回到属性所在的yaml配置文件, 保留文件中的注释和顺序
+// 属性已经存在的时候写入生效的配置文件, 不存在的时候写入根目录config/下对应的配置文件
+// 属性原来的值是加密值的时候, 新的值同样加密之后写入
+func (conf *Config) Set(key string, val interface{}) error {
+	path := strings.Split(key, conf.KeyDelim)
+	file, err := conf.writeFile(path)
+	if err != nil {
+		return err
+	}
+	name, _, ok := parseConfigFileName(filepath.Base(file))
+	if !ok || !isYamlFile(file) {
+		return errors.New("config file " + file + " is not yaml, can not write back")
+	}
+	parts := strings.Split(name, ".")
+	if len(path) <= len(parts) {
+		return errors.New("config key " + key + " should be a key in config file " + file)
+	}
+
+	if plain, ok := val.(string); ok && conf.IsSecret(key) && !IsEncrypted(plain) {
+		if val, err = Encrypt(conf.secretKey, plain); err != nil {
+			return err
+		}
+	}
+
+	var content []byte
+	if util.Exists(file) {
+		if content, err = os.ReadFile(file); err != nil {
+			return err
+		}
+	}
+	content, err = setYamlValue(content, path[len(parts):], val)
+	if err != nil {
+		return errors.New("write config file " + file + " error: " + err.Error())
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode()
+	}
+	if err := os.WriteFile(file, content, mode); err != nil {
+		return err
+	}
+
+	// 立刻重新加载配置文件, 不等待文件监控的通知
+	conf.notify(func() {
+		err = conf.loadConfigFile(filepath.Dir(file), filepath.Base(file))
+	})
+	return err
+}
+
+// writeFile 查找属性需要写入的配置文件
+func (conf *Config) writeFile(path []string) (string, error) {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+
+	// 属性存在的时候, 使用生效的配置文件, 默认值层没有配置文件
+	for i := len(conf.layers) - 1; i >= 0; i-- {
+		layer := conf.layers[i]
+		if searchMap(layer.conf, path) == nil {
+			continue
+		}
+		if layer.name == contract.ConfigLayerRemote {
+			return "", errors.New("config key " + strings.Join(path, conf.KeyDelim) + " comes from remote source, can not write back")
+		}
+		if layer.folder == "" {
+			continue
+		}
+		file := layer.sourceFile(path)
+		if file == "" || strings.Contains(file, ", ") {
+			break
+		}
+		return file, nil
+	}
+
+	// 属性不存在的时候, 使用根目录下文件名最长的对应配置文件
+	var base *configLayer
+	for _, layer := range conf.layers {
+		if layer.name == contract.ConfigLayerBase {
+			base = layer
+		}
+	}
+	if base == nil {
+		return "", errors.New("config base folder not found")
+	}
+	file := ""
+	longest := 0
+	for name, f := range base.files {
+		parts := strings.Split(name, ".")
+		if len(parts) < len(path) && isPrefix(parts, path) && len(parts) > longest {
+			file, longest = f, len(parts)
+		}
+	}
+	if file == "" {
+		file = filepath.Join(base.folder, path[0]+".yaml")
+	}
+	return file, nil
+}
+
+// isYamlFile 判断是否为yaml配置文件
+func isYamlFile(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// setYamlValue 使用yaml的节点修改文件内容中某个路径的值, 保留注释和顺序
+func setYamlValue(content []byte, path []string, val interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	// 空文件或者只有注释的文件
+	if len(doc.Content) == 0 {
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("root of config file should be a map")
+	}
+
+	valNode := &yaml.Node{}
+	if err := valNode.Encode(val); err != nil {
+		return nil, err
+	}
+	setMappingValue(root, path, valNode)
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// setMappingValue 修改map节点中某个路径的值, 路径不存在的时候在最后追加
+func setMappingValue(mapping *yaml.Node, path []string, val *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		old := mapping.Content[i+1]
+		if len(path) == 1 {
+			// 保留原来的值上的注释
+			val.HeadComment, val.LineComment, val.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			mapping.Content[i+1] = val
+			return
+		}
+		if old.Kind != yaml.MappingNode {
+			old = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: old.LineComment}
+			mapping.Content[i+1] = old
+		}
+		setMappingValue(old, path[1:], val)
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, key, val)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, key, child)
+	setMappingValue(child, path[1:], val)
+}
+
+// Keys 获取所有属性的路径, 值为map的属性展开为子属性, 按照字母排序
+func (conf *Config) Keys() []string {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+
+	keys := []string{}
+	var walk func(prefix string, val interface{})
+	walk = func(prefix string, val interface{}) {
+		m, ok := toStringMap(val)
+		if !ok || len(m) == 0 {
+			keys = append(keys, prefix)
+			return
+		}
+		for k, v := range m {
+			walk(prefix+conf.KeyDelim+k, v)
+		}
+	}
+	for name, val := range conf.confMaps {
+		walk(name, val)
+	}
+	sort.Strings(keys)
+	return keys
+}
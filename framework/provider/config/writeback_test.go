@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetYamlValue(t *testing.T) {
+	content := "# 日志配置\ndriver: rotate # 切割日志\nlevel: info\npost_shell:\n  - pwd\n"
+	ret, err := setYamlValue([]byte(content), []string{"driver"}, "single")
+	require.NoError(t, err)
+	assert.Equal(t, "# 日志配置\ndriver: single # 切割日志\nlevel: info\npost_shell:\n  - pwd\n", string(ret))
+
+	ret, err = setYamlValue(ret, []string{"rotate", "size"}, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "# 日志配置\ndriver: single # 切割日志\nlevel: info\npost_shell:\n  - pwd\nrotate:\n  size: 1024\n", string(ret))
+
+	ret, err = setYamlValue([]byte("# 只有注释\n"), []string{"name"}, "goweb")
+	require.NoError(t, err)
+	assert.Contains(t, string(ret), "name: goweb")
+}
+
+func TestConfig_Set(t *testing.T) {
+	encoded, key := newTestSecretKey(t)
+	password, err := Encrypt(key, "123456")
+	require.NoError(t, err)
+
+	base := t.TempDir()
+	baseFile := filepath.Join(base, "app.yaml")
+	envFile := filepath.Join(base, "testing", "app.yaml")
+	require.NoError(t, os.WriteFile(baseFile, []byte("name: goweb # 名称\nurl: http://base\npassword: "+password+"\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "testing"), 0755))
+	require.NoError(t, os.WriteFile(envFile, []byte("url: http://testing\n"), 0644))
+
+	ins, err := NewConfig(nil, base, "testing", map[string]string{SecretKeyEnv: encoded})
+	require.NoError(t, err)
+	conf := ins.(*Config)
+	defer conf.Shutdown(context.Background())
+
+	// 写入生效的配置文件
+	require.NoError(t, conf.Set("app.url", "http://new"))
+	assert.Equal(t, "http://new", conf.GetString("app.url"))
+	bf, _ := os.ReadFile(envFile)
+	assert.Equal(t, "url: http://new\n", string(bf))
+
+	require.NoError(t, conf.Set("app.name", "demo"))
+	bf, _ = os.ReadFile(baseFile)
+	assert.Contains(t, string(bf), "name: demo # 名称\n")
+
+	// 加密的配置项写入新的加密值
+	require.NoError(t, conf.Set("app.password", "654321"))
+	assert.Equal(t, "654321", conf.GetString("app.password"))
+	assert.True(t, conf.IsSecret("app.password"))
+	bf, _ = os.ReadFile(baseFile)
+	assert.NotContains(t, string(bf), "654321")
+
+	// 不存在的配置文件写入根目录
+	require.NoError(t, conf.Set("cache.driver", "memory"))
+	assert.Equal(t, "memory", conf.GetString("cache.driver"))
+	assert.FileExists(t, filepath.Join(base, "cache.yaml"))
+
+	assert.Error(t, conf.Set("app", "x"))
+	assert.Contains(t, conf.Keys(), "app.url")
+}
@@ -29,7 +29,7 @@ func NewLocalDistributedService(params ...interface{}) (interface{}, error) {
 
 // Select 为分布式选择器
 func  (s LocalDistributedService) Select(serviceName string, appID string, holdTime time.Duration) (selectAppID string, err error) {
-	appService := s.container.MustMake(contract.AppKey).(contract.App)
+	appService := framework.MustMake[contract.App](s.container)
 	runtimeFolder := appService.RuntimeFolder()
 	lockFile := filepath.Join(runtimeFolder, "disribute_"+serviceName)
 
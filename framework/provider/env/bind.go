@@ -0,0 +1,144 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind 按照字段的env标签, 将prefix+标签名的环境变量设置到结构体中, target需要是结构体指针
+// 没有设置的环境变量不修改对应的字段, 所以可以先在结构体中写好默认值, 例如:
+//
+//	type DBConfig struct {
+//		Host    string        `env:"HOST"`
+//		Port    int           `env:"PORT"`
+//		Debug   bool          `env:"DEBUG"`
+//		Timeout time.Duration `env:"TIMEOUT"`
+//		Hosts   []string      `env:"HOSTS"` // 逗号分割
+//	}
+//	cfg := DBConfig{Port: 3306}
+//	err := env.Bind("DB_", &cfg) // 读取 DB_HOST, DB_PORT ...
+//
+// 结构体类型的字段, 有env标签的时候使用prefix+标签名作为新的前缀, 没有的时候使用同样的前缀
+func (e *GowebEnv) Bind(prefix string, target interface{}) error {
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.New("env bind target should be a pointer to struct")
+	}
+	return e.bindStruct(prefix, val.Elem())
+}
+
+// bindStruct 设置结构体的每一个字段
+func (e *GowebEnv) bindStruct(prefix string, val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, hasTag := field.Tag.Lookup("env")
+		if name == "-" {
+			continue
+		}
+		fieldVal := val.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			if err := e.bindStruct(prefix+name, fieldVal); err != nil {
+				return err
+			}
+			continue
+		}
+		if !hasTag || name == "" {
+			continue
+		}
+		key := prefix + name
+		raw, ok := e.lookup(key)
+		if !ok {
+			continue
+		}
+		if err := setField(fieldVal, raw); err != nil {
+			return fmt.Errorf("env %s=%q bind to field %s error: %w", key, raw, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// lookup 获取环境变量, 同时返回是否设置
+func (e *GowebEnv) lookup(key string) (string, bool) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	val, ok := e.maps[key]
+	return val, ok
+}
+
+// setField 将字符串转换为字段的类型并且设置
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := cast.ToDurationE(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := cast.ToBoolE(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// 按照十进制解析, 补零的值比如010不会被当作八进制
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(n) {
+			return errors.New("value out of range")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		if field.OverflowUint(n) {
+			return errors.New("value out of range")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := cast.ToFloat64E(raw)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.New("unsupported type " + field.Type().String())
+		}
+		items := splitList(raw)
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		field.Set(slice)
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(elem)
+	default:
+		return errors.New("unsupported type " + field.Type().String())
+	}
+	return nil
+}
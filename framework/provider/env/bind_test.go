@@ -0,0 +1,132 @@
+package env
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnv(maps map[string]string) *GowebEnv {
+	return &GowebEnv{maps: maps}
+}
+
+func TestTypedGetters(t *testing.T) {
+	env := newTestEnv(map[string]string{
+		"PORT":    "8080",
+		"BAD":     "abc",
+		"DEBUG":   "true",
+		"TIMEOUT": "1m30s",
+		"HOSTS":   " a, b ,,c ",
+		"ZERO":    "010",
+		"OCTAL":   "08",
+		"HEX":     "0x10",
+	})
+	assert.Equal(t, 8080, env.GetInt("PORT"))
+	// 补零的值按照十进制解析
+	assert.Equal(t, 10, env.GetInt("ZERO"))
+	assert.Equal(t, 8, env.GetInt("OCTAL"))
+	assert.Equal(t, 0, env.GetInt("HEX"))
+	assert.Equal(t, 0, env.GetInt("BAD"))
+	assert.Equal(t, 0, env.GetInt("NOT_EXIST"))
+	assert.True(t, env.GetBool("DEBUG"))
+	assert.False(t, env.GetBool("NOT_EXIST"))
+	assert.Equal(t, 90*time.Second, env.GetDuration("TIMEOUT"))
+	assert.Equal(t, []string{"a", "b", "c"}, env.GetStringSlice("HOSTS"))
+	assert.Nil(t, env.GetStringSlice("NOT_EXIST"))
+}
+
+func TestSetUnsetAll(t *testing.T) {
+	env := newTestEnv(map[string]string{"A": "1"})
+	all := env.All()
+	all["A"] = "changed"
+	assert.Equal(t, "1", env.Get("A"))
+
+	env.Set("B", "2")
+	assert.True(t, env.IsExist("B"))
+	env.Unset("A")
+	assert.False(t, env.IsExist("A"))
+	assert.Equal(t, map[string]string{"B": "2"}, env.All())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "K" + strconv.Itoa(i)
+			for j := 0; j < 100; j++ {
+				env.Set(key, strconv.Itoa(j))
+				_ = env.Get(key)
+				_ = env.All()
+				env.Unset(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]string{"B": "2"}, env.All())
+}
+
+func TestBind(t *testing.T) {
+	type Pool struct {
+		Size int `env:"SIZE"`
+	}
+	type DB struct {
+		Host    string        `env:"HOST"`
+		Port    int           `env:"PORT"`
+		Debug   bool          `env:"DEBUG"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Hosts   []string      `env:"HOSTS"`
+		Rate    float64       `env:"RATE"`
+		Max     *uint16       `env:"MAX"`
+		Pool    Pool          `env:"POOL_"`
+		Skip    string        `env:"-"`
+		NoTag   string
+	}
+	env := newTestEnv(map[string]string{
+		"DB_HOST":      "127.0.0.1",
+		"DB_DEBUG":     "1",
+		"DB_TIMEOUT":   "5s",
+		"DB_HOSTS":     "a,b",
+		"DB_RATE":      "0.5",
+		"DB_MAX":       "100",
+		"DB_POOL_SIZE": "10",
+		"DB_-":         "x",
+		"PORT":         "1",
+	})
+	db := DB{Port: 3306, Skip: "keep"}
+	require.NoError(t, env.Bind("DB_", &db))
+	assert.Equal(t, "127.0.0.1", db.Host)
+	assert.Equal(t, 3306, db.Port)
+	assert.True(t, db.Debug)
+	assert.Equal(t, 5*time.Second, db.Timeout)
+	assert.Equal(t, []string{"a", "b"}, db.Hosts)
+	assert.Equal(t, 0.5, db.Rate)
+	require.NotNil(t, db.Max)
+	assert.Equal(t, uint16(100), *db.Max)
+	assert.Equal(t, 10, db.Pool.Size)
+	assert.Equal(t, "keep", db.Skip)
+
+	env.Set("DB_PORT", "abc")
+	err := env.Bind("DB_", &db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_PORT")
+
+	// 补零的值按照十进制解析
+	env.Set("DB_PORT", "03306")
+	env.Set("DB_MAX", "08")
+	env.Set("DB_POOL_SIZE", "010")
+	require.NoError(t, env.Bind("DB_", &db))
+	assert.Equal(t, 3306, db.Port)
+	assert.Equal(t, uint16(8), *db.Max)
+	assert.Equal(t, 10, db.Pool.Size)
+
+	env.Set("DB_MAX", "70000")
+	env.Unset("DB_PORT")
+	assert.Error(t, env.Bind("DB_", &db))
+
+	assert.Error(t, env.Bind("DB_", db))
+	assert.Error(t, env.Bind("DB_", (*DB)(nil)))
+}
@@ -0,0 +1,324 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DotenvError 解析.env文件失败时返回的错误, 包含出错的文件和行号
+type DotenvError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *DotenvError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// ParseDotenv 解析.env文件的内容, 支持以下写法:
+//
+//	# 注释                 整行注释
+//	export KEY=value       兼容shell的export前缀
+//	KEY=value # 注释       没有引号的值去掉首尾空白和行尾注释
+//	KEY='value'            单引号中的内容原样保留, 不做转义和变量替换, 可以跨行
+//	KEY="a\nb"             双引号中支持 \n \r \t \" \\ \$ 转义和变量替换, 可以跨行
+//	KEY=a \               行尾的反斜杠表示续行
+//	KEY=${OTHER}           变量替换, 也支持 $OTHER 和 ${OTHER:-default}
+//
+// 变量替换先使用文件中已经定义的变量, 再使用lookup查找
+func ParseDotenv(content string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	p := &dotenvParser{
+		content: strings.ReplaceAll(content, "\r\n", "\n"),
+		line:    1,
+		vars:    map[string]string{},
+		lookup:  lookup,
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+// dotenvParser .env文件的解析器
+type dotenvParser struct {
+	content string
+	pos     int
+	line    int
+	vars    map[string]string
+	lookup  func(key string) (string, bool)
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return &DotenvError{Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *dotenvParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.content) {
+			return nil
+		}
+		switch p.content[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		// export前缀
+		if strings.HasPrefix(p.content[p.pos:], "export") && p.pos+6 < len(p.content) &&
+			(p.content[p.pos+6] == ' ' || p.content[p.pos+6] == '\t') {
+			p.pos += 6
+			p.skipBlank()
+		}
+		key := p.readKey()
+		if key == "" {
+			return p.errorf("invalid variable name in %q", p.rest())
+		}
+		p.skipBlank()
+		if p.pos >= len(p.content) || p.content[p.pos] != '=' {
+			return p.errorf("missing = after %s", key)
+		}
+		p.pos++
+		p.skipBlank()
+
+		value, err := p.readValue()
+		if err != nil {
+			return err
+		}
+		p.vars[key] = value
+	}
+}
+
+// readKey 读取变量名, 变量名由字母, 数字, 下划线, 点和中划线组成
+func (p *dotenvParser) readKey() string {
+	start := p.pos
+	for p.pos < len(p.content) {
+		c := p.content[p.pos]
+		if c == '_' || c == '.' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.content[start:p.pos]
+}
+
+// readValue 读取等号之后的值
+func (p *dotenvParser) readValue() (string, error) {
+	if p.pos >= len(p.content) {
+		return "", nil
+	}
+	var value string
+	var err error
+	switch p.content[p.pos] {
+	case '\'':
+		value, err = p.readQuoted('\'')
+	case '"':
+		var raw string
+		if raw, err = p.readQuoted('"'); err == nil {
+			value, err = p.expand(raw, true)
+		}
+	default:
+		// 行尾的反斜杠表示续行
+		raw := ""
+		for {
+			end := strings.IndexByte(p.content[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.content) - p.pos
+			}
+			part := p.content[p.pos : p.pos+end]
+			p.pos += end
+			if !strings.HasSuffix(part, "\\") || p.pos >= len(p.content) {
+				raw += part
+				break
+			}
+			raw += part[:len(part)-1]
+			p.pos++
+			p.line++
+		}
+		// 空白之后的#为注释
+		for i := 0; i < len(raw); i++ {
+			if raw[i] == '#' && (i == 0 || raw[i-1] == ' ' || raw[i-1] == '\t') {
+				raw = raw[:i]
+				break
+			}
+		}
+		return p.expand(strings.TrimSpace(raw), false)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 引号之后只能有空白和注释
+	p.skipBlank()
+	if p.pos < len(p.content) && p.content[p.pos] == '#' {
+		p.skipLine()
+	}
+	if p.pos < len(p.content) && p.content[p.pos] != '\n' {
+		return "", p.errorf("unexpected character after quoted value: %q", p.rest())
+	}
+	return value, nil
+}
+
+// readQuoted 读取引号中的内容, 双引号中的转义字符保留给expand处理
+func (p *dotenvParser) readQuoted(quote byte) (string, error) {
+	startLine := p.line
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.content) {
+		c := p.content[p.pos]
+		switch {
+		case c == '\\' && quote == '"' && p.pos+1 < len(p.content):
+			if p.content[p.pos+1] == '\n' {
+				p.line++
+			}
+			p.pos += 2
+			continue
+		case c == quote:
+			value := p.content[start:p.pos]
+			p.pos++
+			return value, nil
+		case c == '\n':
+			p.line++
+		}
+		p.pos++
+	}
+	return "", &DotenvError{Line: startLine, Message: fmt.Sprintf("unclosed quote %c", quote)}
+}
+
+// expand 处理转义字符和变量替换, escape为true的时候处理双引号中的转义字符
+func (p *dotenvParser) expand(raw string, escape bool) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c == '\\' && i+1 < len(raw) {
+			next := raw[i+1]
+			if escape {
+				switch next {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 't':
+					buf.WriteByte('\t')
+				case '"', '\\', '$':
+					buf.WriteByte(next)
+				case '\n':
+					// 行尾的反斜杠表示续行
+				default:
+					buf.WriteByte(c)
+					buf.WriteByte(next)
+				}
+				i++
+				continue
+			}
+			if next == '$' {
+				buf.WriteByte('$')
+				i++
+				continue
+			}
+		}
+		if c != '$' || i+1 >= len(raw) {
+			buf.WriteByte(c)
+			continue
+		}
+
+		// ${KEY} 或者 ${KEY:-default}
+		if raw[i+1] == '{' {
+			end := matchBrace(raw[i+2:])
+			if end < 0 {
+				return "", p.errorf("unclosed variable %s", raw[i:])
+			}
+			expr := raw[i+2 : i+2+end]
+			key, def, hasDef := expr, "", false
+			if index := strings.Index(expr, ":-"); index >= 0 {
+				key, def, hasDef = expr[:index], expr[index+2:], true
+			}
+			val := p.resolve(key)
+			if val == "" && hasDef {
+				var err error
+				if val, err = p.expand(def, false); err != nil {
+					return "", err
+				}
+			}
+			buf.WriteString(val)
+			i += 2 + end
+			continue
+		}
+		// $KEY
+		j := i + 1
+		for j < len(raw) && (raw[j] == '_' || (raw[j] >= 'a' && raw[j] <= 'z') || (raw[j] >= 'A' && raw[j] <= 'Z') || (raw[j] >= '0' && raw[j] <= '9')) {
+			j++
+		}
+		if j == i+1 {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteString(p.resolve(raw[i+1 : j]))
+		i = j - 1
+	}
+	return buf.String(), nil
+}
+
+// matchBrace 查找和 ${ 匹配的 } 的位置, 中间可以嵌套 ${...}, 找不到的时候返回-1
+func matchBrace(content string) int {
+	depth := 0
+	for i := 0; i < len(content); i++ {
+		switch {
+		case content[i] == '$' && i+1 < len(content) && content[i+1] == '{':
+			depth++
+			i++
+		case content[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// resolve 查找变量的值, 先使用文件中已经定义的变量
+func (p *dotenvParser) resolve(key string) string {
+	if val, ok := p.vars[key]; ok {
+		return val
+	}
+	if p.lookup != nil {
+		if val, ok := p.lookup(key); ok {
+			return val
+		}
+	}
+	return ""
+}
+
+// skipBlank 跳过空格和制表符
+func (p *dotenvParser) skipBlank() {
+	for p.pos < len(p.content) && (p.content[p.pos] == ' ' || p.content[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine 跳过当前行剩余的内容, 不包括换行符
+func (p *dotenvParser) skipLine() {
+	if end := strings.IndexByte(p.content[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+		return
+	}
+	p.pos = len(p.content)
+}
+
+// rest 当前行剩余的内容, 用于错误信息
+func (p *dotenvParser) rest() string {
+	if end := strings.IndexByte(p.content[p.pos:], '\n'); end >= 0 {
+		return p.content[p.pos : p.pos+end]
+	}
+	return p.content[p.pos:]
+}
@@ -0,0 +1,94 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := strings.Join([]string{
+		"# 注释",
+		"",
+		"export HOST = 127.0.0.1",
+		"PORT=3306 # 端口",
+		"URL=mysql://${HOST}:$PORT/db",
+		"HASH=a#b",
+		"SINGLE='raw ${HOST} \\n'",
+		`DOUBLE="line1\nline2 \"q\" \$HOST ${HOST}" # 注释`,
+		"MULTI=\"first",
+		"second\"",
+		"EMPTY=",
+		"DEFAULT=${NOT_EXIST:-${HOST}}",
+		"OUTER=${FROM_LOOKUP}",
+		"CONT=a \\",
+		"b",
+		"LONG=" + strings.Repeat("x", 10000),
+	}, "\r\n")
+	vars, err := ParseDotenv(content, func(key string) (string, bool) {
+		if key == "FROM_LOOKUP" {
+			return "lookup", true
+		}
+		return "", false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"HOST":    "127.0.0.1",
+		"PORT":    "3306",
+		"URL":     "mysql://127.0.0.1:3306/db",
+		"HASH":    "a#b",
+		"SINGLE":  "raw ${HOST} \\n",
+		"DOUBLE":  "line1\nline2 \"q\" $HOST 127.0.0.1",
+		"MULTI":   "first\nsecond",
+		"EMPTY":   "",
+		"DEFAULT": "127.0.0.1",
+		"OUTER":   "lookup",
+		"CONT":    "a b",
+		"LONG":    strings.Repeat("x", 10000),
+	}, vars)
+
+	errCases := map[string]string{
+		"A=1\nBAD LINE":     "line 2: missing = after BAD",
+		"A=1\n\nB=\"open\n": "line 3: unclosed quote \"",
+		"A='x' y":           "line 1: unexpected character after quoted value: \"y\"",
+		"=1":                "line 1: invalid variable name in \"=1\"",
+	}
+	for content, want := range errCases {
+		_, err := ParseDotenv(content, nil)
+		assert.EqualError(t, err, want, content)
+	}
+}
+
+func TestNewEnv_Files(t *testing.T) {
+	folder := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(folder, name), []byte(content), 0644))
+	}
+	writeFile(".env", "APP_ENV=testing\nNAME=base\nURL=http://${NAME}\nONLY_BASE=1\n")
+	writeFile(".env.testing", "NAME=testing\nURL=http://${NAME}\n")
+	writeFile(".env.production", "NAME=production\n")
+	writeFile(".env.local", "NAME=local\n")
+
+	os.Unsetenv("APP_ENV")
+	ins, err := NewEnv(folder)
+	require.NoError(t, err)
+	env := ins.(*GowebEnv)
+	assert.Equal(t, "testing", env.AppEnv())
+	assert.Equal(t, "local", env.Get("NAME"))
+	assert.Equal(t, "http://testing", env.Get("URL"))
+	assert.Equal(t, "1", env.Get("ONLY_BASE"))
+
+	// 运行环境的变量覆盖所有的文件
+	t.Setenv("NAME", "os")
+	ins, err = NewEnv(folder)
+	require.NoError(t, err)
+	assert.Equal(t, "os", ins.(*GowebEnv).Get("NAME"))
+
+	writeFile(".env.local", "NAME=local\nBAD\n")
+	_, err = NewEnv(folder)
+	assert.EqualError(t, err, filepath.Join(folder, ".env.local")+":2: missing = after BAD")
+}
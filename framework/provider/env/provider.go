@@ -16,7 +16,7 @@ func (provider *EnvProvider) Register(c framework.Container) framework.NewInstan
 
 // Boot will called when the service instantiate
 func (provider *EnvProvider) Boot(c framework.Container) error {
-	app := c.MustMake(contract.AppKey).(contract.App)
+	app := framework.MustMake[contract.App](c)
 	provider.Folder = app.BaseFolder()
 	return nil
 }
@@ -1,29 +1,32 @@
 package env
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
 	"goweb/framework/contract"
-	"io"
+	"goweb/framework/util"
 	"os"
-	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
 )
 
 type GowebEnv struct {
 	forlder string            // 代表.env所在的目录
 	maps    map[string]string // 保存所有的环境变量
+	lock    sync.RWMutex      // 保护maps, Set和Unset可以和读取并发调用
 
 }
 
 // NewEnv 有一个参数，.env文件所在的目录
-// example: NewHadeEnv("/envfolder/") 会读取文件: /envfolder/.env
-// .env的文件格式 FOO_ENV=BAR
-
+// example: NewEnv("/envfolder/") 会按照顺序读取文件: /envfolder/.env, /envfolder/.env.{APP_ENV}, /envfolder/.env.local
+// 后面的文件覆盖前面的文件, 当前程序的环境变量覆盖所有的文件, .env的文件格式见ParseDotenv
 func NewEnv(params ...interface{}) (interface{}, error) {
 	if len(params) != 1 {
-		return nil, errors.New("NewHadeEnv param error")
+		return nil, errors.New("NewEnv param error")
 	}
 	//读取folder文件
 	folder := params[0].(string)
@@ -34,32 +37,20 @@ func NewEnv(params ...interface{}) (interface{}, error) {
 		maps: map[string]string{"APP_ENV": contract.EnvDevelopment},
 	}
 
-	// 解析folder/.env文件
-	file := path.Join(folder,".env")
-
-	// 读取.env文件, 不管任意失败，都不影响后续
-	// 打开文件.env
-	fi, err := os.Open(file)
-	if err == nil {
-		defer fi.Close()
-		// 读取文件
-		br := bufio.NewReader(fi)
-		for{
-			//按照行数读取
-			line, _ , c := br.ReadLine()
-			if c == io.EOF {
-				break
-			}
-			s := bytes.SplitN(line, []byte{'='},2)
-			if len(s) < 2 {
-				continue
-			}
-			// 保存map
-			key 	:= string(s[0])
-			value 	:= string(s[1])
-			env.maps[key] = value
+	// 先读取.env文件, 确定APP_ENV之后再读取对应环境的文件
+	if err := env.loadFile(".env"); err != nil {
+		return nil, err
+	}
+	appEnv := env.maps["APP_ENV"]
+	if val, ok := os.LookupEnv("APP_ENV"); ok && val != "" {
+		appEnv = val
+	}
+	for _, file := range []string{".env." + appEnv, ".env.local"} {
+		if err := env.loadFile(file); err != nil {
+			return nil, err
 		}
 	}
+
 	// 获取当前程序的环境变量，并且覆盖.env文件下的变量
 	for _, e := range os.Environ() {
 		pair := strings.SplitN(e, "=", 2)
@@ -73,6 +64,36 @@ func NewEnv(params ...interface{}) (interface{}, error) {
 	return env,nil
 }
 
+// loadFile 读取folder下的某个.env文件, 文件不存在的时候跳过, 格式错误的时候返回文件和行号
+func (e *GowebEnv) loadFile(name string) error {
+	file := filepath.Join(e.forlder, name)
+	if !util.Exists(file) {
+		return nil
+	}
+	bf, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	// 变量替换的时候, 当前程序的环境变量优先, 其次是之前的文件中定义的变量
+	vars, err := ParseDotenv(string(bf), func(key string) (string, bool) {
+		if val, ok := os.LookupEnv(key); ok {
+			return val, true
+		}
+		val, ok := e.maps[key]
+		return val, ok
+	})
+	if err != nil {
+		if dotenvErr, ok := err.(*DotenvError); ok {
+			dotenvErr.File = file
+		}
+		return err
+	}
+	for key, val := range vars {
+		e.maps[key] = val
+	}
+	return nil
+}
+
 // AppEnv 获取表示当前APP环境的变量APP_ENV
 func (e *GowebEnv) AppEnv() string {
 	return e.Get("APP_ENV")
@@ -80,19 +101,75 @@ func (e *GowebEnv) AppEnv() string {
 
 // Get 获取某个环境变量，如果没有设置，返回""
 func (e *GowebEnv) Get(key string) string {
-	if val, ok := e.maps[key];ok {
-		return val
-	}
-	return ""
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.maps[key]
 }
 
 // IsExist 判断一个环境变量是否有被设置
 func (e *GowebEnv) IsExist(key string) bool {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
 	_, ok := e.maps[key]
 	return ok
 }
 
-// All 获取所有的环境变量，.env和运行环境变量融合后结果
+// GetInt 获取int类型的环境变量, 按照十进制解析, 例如: 010为10, 没有设置或者格式错误的时候返回0
+func (e *GowebEnv) GetInt(key string) int {
+	n, err := strconv.ParseInt(strings.TrimSpace(e.Get(key)), 10, 0)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// GetBool 获取bool类型的环境变量, 支持 1/t/true/0/f/false 等写法
+func (e *GowebEnv) GetBool(key string) bool {
+	return cast.ToBool(e.Get(key))
+}
+
+// GetDuration 获取时间间隔类型的环境变量, 例如: 10s, 1h30m, 纯数字的时候单位为纳秒
+func (e *GowebEnv) GetDuration(key string) time.Duration {
+	return cast.ToDuration(e.Get(key))
+}
+
+// GetStringSlice 获取逗号分割的环境变量, 去掉每一项首尾的空白, 没有设置的时候返回nil
+func (e *GowebEnv) GetStringSlice(key string) []string {
+	return splitList(e.Get(key))
+}
+
+// Set 设置某个环境变量, 只修改服务中的值, 不修改当前进程的环境变量, 一般用于测试
+func (e *GowebEnv) Set(key string, val string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.maps[key] = val
+}
+
+// Unset 删除某个环境变量, 只修改服务中的值, 一般用于测试
+func (e *GowebEnv) Unset(key string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.maps, key)
+}
+
+// All 获取所有的环境变量，.env和运行环境变量融合后结果, 返回的是副本, 修改不影响服务
 func (e *GowebEnv) All() map[string]string {
-	return e.maps
-}
\ No newline at end of file
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	ret := make(map[string]string, len(e.maps))
+	for key, val := range e.maps {
+		ret[key] = val
+	}
+	return ret
+}
+
+// splitList 按照逗号分割, 去掉每一项首尾的空白和空项
+func splitList(val string) []string {
+	var ret []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
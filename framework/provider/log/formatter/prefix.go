@@ -2,6 +2,35 @@ package formatter
 
 import "goweb/framework/contract"
 
+const (
+	// FieldModule 模块名的字段, 由Named设置
+	FieldModule = "module"
+	// FieldCaller 调用日志的位置的字段, 例如: demo/api.go:25, 配置log.caller为true的时候设置
+	FieldCaller = "caller"
+)
+
+// splitFields 取出模块名和调用位置, 返回剩下的字段, 不修改传入的fields
+func splitFields(fields map[string]interface{}) (module string, caller string, rest map[string]interface{}) {
+	m, hasModule := fields[FieldModule]
+	c, hasCaller := fields[FieldCaller]
+	if !hasModule && !hasCaller {
+		return "", "", fields
+	}
+	rest = make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if k != FieldModule && k != FieldCaller {
+			rest[k] = v
+		}
+	}
+	if hasModule {
+		module, _ = m.(string)
+	}
+	if hasCaller {
+		caller, _ = c.(string)
+	}
+	return module, caller, rest
+}
+
 func Prefix(level contract.LogLevel) string {
 	prefix := ""
 	switch level {
@@ -22,6 +22,17 @@ func TextFormatter(level contract.LogLevel, t time.Time, msg string, fields map[
 	bf.WriteString(ts)
 	bf.WriteString(Separator)
 
+	// 输出模块名和调用位置, 格式为: [module] file:line
+	module, caller, fields := splitFields(fields)
+	if module != "" {
+		bf.WriteString("[" + module + "]")
+		bf.WriteString(Separator)
+	}
+	if caller != "" {
+		bf.WriteString(caller)
+		bf.WriteString(Separator)
+	}
+
 	// 输出msg
 	bf.WriteString("\"")
 	bf.WriteString(msg)
@@ -7,6 +7,8 @@ import (
 	"goweb/framework/provider/log/services"
 	"io"
 	"strings"
+
+	"github.com/spf13/cast"
 )
 
 // HadeLogServiceProvider 服务提供者
@@ -28,6 +30,26 @@ type LogServiceProvider struct {
 
 // Register 注册一个服务实例
 func(l *LogServiceProvider)  Register(c framework.Container) framework.NewInstance {
+	newLog := l.driver(c)
+	// 实例化之后监听配置文件中日志级别和格式的变化
+	return func(params ...interface{}) (interface{}, error) {
+		ins, err := newLog(params...)
+		if err != nil {
+			return nil, err
+		}
+		// 开启异步写入, 容器Shutdown的时候写入缓冲区中剩余的日志
+		if options, ok := asyncOptions(c); ok {
+			if log, ok := ins.(interface{ SetAsync(services.AsyncOptions) }); ok {
+				log.SetAsync(options)
+			}
+		}
+		l.watchConfig(c, ins.(contract.Log))
+		return ins, nil
+	}
+}
+
+// driver 根据driver的配置项确定实例化方法
+func (l *LogServiceProvider) driver(c framework.Container) framework.NewInstance {
 	if l.Driver == "" {
 		tcs, err := c.Make(contract.ConfigKey)
 		if err != nil {
@@ -35,7 +57,7 @@ func(l *LogServiceProvider)  Register(c framework.Container) framework.NewInstan
 			return services.NewConsoleLog
 		}
 		cs := tcs.(contract.Config)
-		l.Driver = strings.ToLower(cs.GetString("log.Driver"))
+		l.Driver = strings.ToLower(cs.GetString("log.driver"))
 	}
 	// 根据driver的配置项确定
 	switch l.Driver {
@@ -47,13 +69,91 @@ func(l *LogServiceProvider)  Register(c framework.Container) framework.NewInstan
 		return services.NewConsoleLog
 	case "custom":
 		return services.NewCustomLog
+	case "multi":
+		return services.NewMultiLog
 	default:
 		return services.NewConsoleLog
 	}
 }
 
+// watchConfig 配置文件中的log.level, log.formatter, log.caller和log.sampling变化的时候, 重新设置日志服务
+func (l *LogServiceProvider) watchConfig(c framework.Container, log contract.Log) {
+	configService, err := framework.Make[contract.Config](c)
+	if err != nil {
+		return
+	}
+	configService.Watch("log.level", func(old, new interface{}) {
+		level := logLevel(cast.ToString(new))
+		if level == contract.UnknownLevel {
+			level = contract.InfoLevel
+		}
+		log.SetLevel(level)
+	})
+	// log.caller 是否记录调用的文件和行号
+	if callerLog, ok := log.(interface{ SetCaller(bool) }); ok {
+		callerLog.SetCaller(configService.GetBool("log.caller"))
+		configService.Watch("log.caller", func(old, new interface{}) {
+			callerLog.SetCaller(cast.ToBool(new))
+		})
+	}
+	// log.sampling 日志采样
+	if sampleLog, ok := log.(samplingLog); ok {
+		setSampling(configService, sampleLog)
+		configService.Watch("log.sampling", func(old, new interface{}) {
+			setSampling(configService, sampleLog)
+		})
+	}
+	configService.Watch("log.formatter", func(old, new interface{}) {
+		if f := logFormatter(cast.ToString(new)); f != nil {
+			log.SetFormatter(f)
+			return
+		}
+		log.SetFormatter(formatter.TextFormatter)
+	})
+}
+
+// asyncOptions 读取log.async中异步写入的配置, log.async.enable为true的时候开启
+func asyncOptions(c framework.Container) (services.AsyncOptions, bool) {
+	configService, err := framework.Make[contract.Config](c)
+	if err != nil || !configService.GetBool("log.async.enable") {
+		return services.AsyncOptions{}, false
+	}
+	return services.AsyncOptions{
+		BufferSize:    configService.GetInt("log.async.buffer_size"),
+		BatchSize:     configService.GetInt("log.async.batch_size"),
+		FlushInterval: cast.ToDuration(configService.GetString("log.async.flush_interval")),
+		Overflow:      configService.GetString("log.async.overflow"),
+	}, true
+}
+
+// samplingLog 支持采样的日志服务, 所有的驱动都支持
+type samplingLog interface {
+	SetSampling(options services.SamplingOptions)
+	DisableSampling()
+}
+
+// setSampling 读取log.sampling中采样的配置, log.sampling.enable为true的时候开启
+func setSampling(configService contract.Config, log samplingLog) {
+	if !configService.GetBool("log.sampling.enable") {
+		log.DisableSampling()
+		return
+	}
+	log.SetSampling(services.SamplingOptions{
+		Interval:   cast.ToDuration(configService.GetString("log.sampling.interval")),
+		First:      configService.GetInt("log.sampling.first"),
+		Thereafter: configService.GetInt("log.sampling.thereafter"),
+		Key:        configService.GetString("log.sampling.key"),
+		Level:      logLevel(configService.GetString("log.sampling.level")),
+	})
+}
+
 // Boot 启动的时候注入
 func (l *LogServiceProvider) Boot(c framework.Container) error {
+	// 设置日志配置的默认值, 配置文件中没有设置的时候使用
+	if configService, err := framework.Make[contract.Config](c); err == nil {
+		configService.SetDefault("log.level", "info")
+		configService.SetDefault("log.formatter", "text")
+	}
 	return nil
 }
 
@@ -66,17 +166,14 @@ func (l *LogServiceProvider) IsDefer() bool {
 // Params 定义要传递给实例化方法的参数
 func (l *LogServiceProvider) Params(c framework.Container) []interface{} {
 	// 获取configService
-	configService := c.MustMake(contract.ConfigKey).(contract.Config)
+	configService := framework.MustMake[contract.Config](c)
 
 	// 设置参数formatter
 	if l.Formatter == nil {
 		l.Formatter = formatter.TextFormatter
 		if configService.IsExist("log.formatter") {
-			v := configService.GetString("log.formatter")
-			if v == "json" {
-				l.Formatter = formatter.JsonFormatter
-			} else if v == "text" {
-				l.Formatter = formatter.TextFormatter
+			if f := logFormatter(configService.GetString("log.formatter")); f != nil {
+				l.Formatter = f
 			}
 		}
 	}
@@ -88,8 +185,32 @@ func (l *LogServiceProvider) Params(c framework.Container) []interface{} {
 		}
 	}
 
-	// 定义5个参数
-	return []interface{}{c, l.Level, l.CtxFielder, l.Formatter, l.Output}
+	// 定义6个参数, 最后一个为log.sinks中的配置, 只在multi驱动中使用
+	// Params在Register之前调用, 这个时候还不能确定driver
+	return []interface{}{c, l.Level, l.CtxFielder, l.Formatter, l.Output, logSinks(configService)}
+}
+
+// logSinks 读取log.sinks中每个输出的配置, 没有设置folder的时候使用log.folder
+func logSinks(configService contract.Config) []services.LogSinkConfig {
+	sinks := []services.LogSinkConfig{}
+	for _, item := range cast.ToSlice(configService.Get("log.sinks")) {
+		// 复制一份, 不修改配置服务中的值
+		options := map[string]interface{}{}
+		for k, v := range cast.ToStringMap(item) {
+			options[k] = v
+		}
+		sink := services.LogSinkConfig{
+			Driver:    cast.ToString(options["driver"]),
+			Level:     logLevel(cast.ToString(options["level"])),
+			Formatter: logFormatter(cast.ToString(options["formatter"])),
+			Options:   options,
+		}
+		if _, ok := options["folder"]; !ok && configService.IsExist("log.folder") {
+			options["folder"] = configService.GetString("log.folder")
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks
 }
 
 // Name 定义对应的服务字符串凭证
@@ -116,4 +237,15 @@ func logLevel(config string) contract.LogLevel {
 		return contract.TraceLevel
 	}
 	return contract.UnknownLevel
-}
\ No newline at end of file
+}
+
+// logFormatter get formatter from string
+func logFormatter(config string) contract.Formatter {
+	switch config {
+	case "json":
+		return formatter.JsonFormatter
+	case "text":
+		return formatter.TextFormatter
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package log
+
+import "goweb/framework/provider/config"
+
+// logSchema log.yaml的配置结构, 启动和config validate的时候校验
+type logSchema struct {
+	Driver      string            `yaml:"driver" validate:"omitempty,oneof=console single rotate custom multi"`
+	Level       string            `yaml:"level" validate:"omitempty,oneof=panic fatal error warn info debug trace"`
+	Formatter   string            `yaml:"formatter" validate:"omitempty,oneof=text json"`
+	Caller      bool              `yaml:"caller"`
+	Folder      string            `yaml:"folder"`
+	File        string            `yaml:"file"`
+	DateFormat  string            `yaml:"date_format"`
+	RotateCount int               `yaml:"rotate_count" validate:"gte=0"`
+	RotateSize  int               `yaml:"rotate_size" validate:"gte=0"`
+	RotateTime  string            `yaml:"rotate_time" validate:"duration"`
+	MaxAge      string            `yaml:"max_age" validate:"duration,excluded_with=RotateCount"`
+	Sinks       []logSinkSchema   `yaml:"sinks" validate:"required_if=Driver multi,dive"`
+	Async       logAsyncSchema    `yaml:"async"`
+	Sampling    logSamplingSchema `yaml:"sampling"`
+}
+
+// logSamplingSchema log.sampling日志采样的配置结构
+type logSamplingSchema struct {
+	Enable     bool   `yaml:"enable"`
+	Interval   string `yaml:"interval" validate:"duration"`
+	First      int    `yaml:"first" validate:"gte=0"`
+	Thereafter int    `yaml:"thereafter" validate:"gte=-1"`
+	Key        string `yaml:"key"`
+	Level      string `yaml:"level" validate:"omitempty,oneof=panic fatal error warn info debug trace"`
+}
+
+// logAsyncSchema log.async异步写入的配置结构
+type logAsyncSchema struct {
+	Enable        bool   `yaml:"enable"`
+	BufferSize    int    `yaml:"buffer_size" validate:"gte=0"`
+	BatchSize     int    `yaml:"batch_size" validate:"gte=0"`
+	FlushInterval string `yaml:"flush_interval" validate:"duration"`
+	Overflow      string `yaml:"overflow" validate:"omitempty,oneof=block drop_newest drop_oldest"`
+}
+
+// logSinkSchema multi驱动下log.sinks中每一项的配置结构
+type logSinkSchema struct {
+	Driver      string `yaml:"driver" validate:"required,oneof=console single rotate custom"`
+	Level       string `yaml:"level" validate:"omitempty,oneof=panic fatal error warn info debug trace"`
+	Formatter   string `yaml:"formatter" validate:"omitempty,oneof=text json"`
+	Folder      string `yaml:"folder"`
+	File        string `yaml:"file"`
+	DateFormat  string `yaml:"date_format"`
+	RotateCount int    `yaml:"rotate_count" validate:"gte=0"`
+	RotateSize  int    `yaml:"rotate_size" validate:"gte=0"`
+	RotateTime  string `yaml:"rotate_time" validate:"duration"`
+	MaxAge      string `yaml:"max_age" validate:"duration,excluded_with=RotateCount"`
+}
+
+func init() {
+	config.RegisterSchema("log", logSchema{})
+}
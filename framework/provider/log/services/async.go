@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	pkgLog "log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// OverflowBlock 缓冲区满的时候阻塞写日志的goroutine, 直到有空间, 不丢日志
+	OverflowBlock = "block"
+	// OverflowDropNewest 缓冲区满的时候丢弃当前这条日志
+	OverflowDropNewest = "drop_newest"
+	// OverflowDropOldest 缓冲区满的时候丢弃缓冲区中最早的一条日志
+	OverflowDropOldest = "drop_oldest"
+)
+
+const (
+	defaultAsyncBufferSize    = 4096
+	defaultAsyncBatchSize     = 128
+	defaultAsyncFlushInterval = time.Second
+)
+
+// AsyncOptions 异步写日志的配置, 对应log.yaml中的log.async
+type AsyncOptions struct {
+	// BufferSize 缓冲区最多保存的日志条数, 默认4096
+	BufferSize int
+	// BatchSize 每次最多合并写入的日志条数, 缓冲区中的日志达到这个数量的时候立刻写入, 默认128
+	BatchSize int
+	// FlushInterval 日志不足BatchSize的时候, 最长等待多久写入, 默认1s
+	FlushInterval time.Duration
+	// Overflow 缓冲区满的时候的处理方式, 支持 block/drop_newest/drop_oldest, 默认block
+	Overflow string
+}
+
+// AsyncWriter 将写入的内容保存在有界的环形缓冲区中, 由后台goroutine批量写入output
+type AsyncWriter struct {
+	// 计数器放在最前面保证原子操作的对齐
+	dropped     uint64 // 缓冲区满的时候丢弃的日志条数
+	writeErrors uint64 // 写入output失败的次数
+	failed      int32  // 上一次写入是否失败, 只在失败和恢复的时候打印提示
+
+	output  io.Writer
+	options AsyncOptions
+
+	lock    sync.Mutex
+	notFull *sync.Cond // block模式下等待缓冲区有空间
+	ring    [][]byte   // 环形缓冲区, 每一项为一条日志
+	head    int        // 最早的一条日志的位置
+	count   int        // 缓冲区中的日志条数
+	closed  bool
+
+	wake chan struct{} // 缓冲区中的日志达到BatchSize的时候通知后台goroutine
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewAsyncWriter 创建异步writer并且启动后台写入的goroutine, 使用完需要调用Close
+func NewAsyncWriter(output io.Writer, options AsyncOptions) *AsyncWriter {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultAsyncBufferSize
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultAsyncBatchSize
+	}
+	if options.BatchSize > options.BufferSize {
+		options.BatchSize = options.BufferSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultAsyncFlushInterval
+	}
+	if options.Overflow == "" {
+		options.Overflow = OverflowBlock
+	}
+	w := &AsyncWriter{
+		output:  output,
+		options: options,
+		ring:    make([][]byte, options.BufferSize),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.lock)
+	go w.run()
+	return w
+}
+
+// Write 复制一份内容放入缓冲区, 缓冲区满的时候按照Overflow处理
+// Close之后直接写入output
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.lock.Lock()
+	for w.count == len(w.ring) && !w.closed {
+		switch w.options.Overflow {
+		case OverflowDropNewest:
+			w.lock.Unlock()
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.count--
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		w.lock.Unlock()
+		return len(p), w.writeOutput(entry)
+	}
+	w.ring[(w.head+w.count)%len(w.ring)] = entry
+	w.count++
+	full := w.count >= w.options.BatchSize
+	w.lock.Unlock()
+
+	if full {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// run 后台goroutine, 缓冲区中的日志达到BatchSize或者到了FlushInterval的时候写入
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			w.flush()
+			return
+		case <-w.wake:
+		case <-ticker.C:
+		}
+		w.flush()
+	}
+}
+
+// flush 将缓冲区中的日志全部写入output, 每次最多合并BatchSize条
+func (w *AsyncWriter) flush() {
+	for {
+		w.lock.Lock()
+		n := w.count
+		if n > w.options.BatchSize {
+			n = w.options.BatchSize
+		}
+		if n == 0 {
+			w.lock.Unlock()
+			return
+		}
+		size := 0
+		batch := make([][]byte, n)
+		for i := 0; i < n; i++ {
+			index := (w.head + i) % len(w.ring)
+			batch[i] = w.ring[index]
+			w.ring[index] = nil
+			size += len(batch[i])
+		}
+		w.head = (w.head + n) % len(w.ring)
+		w.count -= n
+		w.notFull.Broadcast()
+		w.lock.Unlock()
+
+		buf := make([]byte, 0, size)
+		for _, entry := range batch {
+			buf = append(buf, entry...)
+		}
+		w.writeOutput(buf)
+	}
+}
+
+// writeOutput 写入output, 记录失败的次数
+func (w *AsyncWriter) writeOutput(p []byte) error {
+	_, err := w.output.Write(p)
+	if err != nil {
+		atomic.AddUint64(&w.writeErrors, 1)
+		if atomic.CompareAndSwapInt32(&w.failed, 0, 1) {
+			pkgLog.Println("async log write error:", err)
+		}
+		return err
+	}
+	if atomic.CompareAndSwapInt32(&w.failed, 1, 0) {
+		pkgLog.Println("async log write recovered")
+	}
+	return nil
+}
+
+// Close 不再接收新的日志, 等待缓冲区中的日志全部写入, ctx结束的时候不再等待
+func (w *AsyncWriter) Close(ctx context.Context) error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	// 唤醒block模式下等待的goroutine, 它们会直接写入output
+	w.notFull.Broadcast()
+	w.lock.Unlock()
+
+	close(w.stop)
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("flush async log: " + ctx.Err().Error())
+	}
+}
+
+// Buffered 缓冲区中还没有写入的日志条数
+func (w *AsyncWriter) Buffered() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.count
+}
+
+// Dropped 缓冲区满的时候丢弃的日志条数
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// WriteErrors 写入output失败的次数
+func (w *AsyncWriter) WriteErrors() uint64 {
+	return atomic.LoadUint64(&w.writeErrors)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gateWriter 在gate关闭之前阻塞写入, 用于填满缓冲区
+type gateWriter struct {
+	gate chan struct{}
+
+	lock   sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) lines() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return strings.Fields(w.buf.String())
+}
+
+// fillBuffer 写入第一条日志并等待后台goroutine取走, 阻塞在output上, 之后的日志留在缓冲区中
+func fillBuffer(t *testing.T, w *AsyncWriter, n int) {
+	w.Write([]byte("0\n"))
+	require.Eventually(t, func() bool { return w.Buffered() == 0 }, time.Second, time.Millisecond)
+	for i := 1; i <= n; i++ {
+		w.Write([]byte(strconv.Itoa(i) + "\n"))
+	}
+}
+
+func TestAsyncWriter_Batch(t *testing.T) {
+	out := newGateWriter()
+	close(out.gate)
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 100, BatchSize: 10, FlushInterval: time.Hour})
+	for i := 0; i < 25; i++ {
+		w.Write([]byte(strconv.Itoa(i) + "\n"))
+	}
+	// 达到batch_size的部分立刻写入, 剩下的等待Close
+	require.Eventually(t, func() bool { return len(out.lines()) >= 20 }, time.Second, time.Millisecond)
+	require.NoError(t, w.Close(context.Background()))
+	lines := out.lines()
+	require.Len(t, lines, 25)
+	for i, line := range lines {
+		assert.Equal(t, strconv.Itoa(i), line)
+	}
+	assert.LessOrEqual(t, out.writes, 4)
+
+	// Close之后直接写入
+	w.Write([]byte("after\n"))
+	assert.Equal(t, "after", out.lines()[25])
+}
+
+func TestAsyncWriter_Overflow(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 3, BatchSize: 1, FlushInterval: time.Hour, Overflow: OverflowDropNewest})
+	fillBuffer(t, w, 5)
+	assert.Equal(t, 3, w.Buffered())
+	assert.Equal(t, uint64(2), w.Dropped())
+	close(out.gate)
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []string{"0", "1", "2", "3"}, out.lines())
+
+	out = newGateWriter()
+	w = NewAsyncWriter(out, AsyncOptions{BufferSize: 3, BatchSize: 1, FlushInterval: time.Hour, Overflow: OverflowDropOldest})
+	fillBuffer(t, w, 5)
+	assert.Equal(t, uint64(2), w.Dropped())
+	close(out.gate)
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []string{"0", "3", "4", "5"}, out.lines())
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 2, BatchSize: 1, FlushInterval: time.Hour})
+	fillBuffer(t, w, 2)
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("3\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("write should block when buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(out.gate)
+	<-written
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, []string{"0", "1", "2", "3"}, out.lines())
+	assert.Equal(t, uint64(0), w.Dropped())
+}
+
+func TestLog_Async(t *testing.T) {
+	out := newGateWriter()
+	log := &ConsoleLog{}
+	log.c = framework.NewContainer()
+	log.SetLevel(contract.InfoLevel)
+	log.SetOutput(out)
+	log.SetAsync(AsyncOptions{BufferSize: 10, FlushInterval: time.Hour})
+
+	log.Info(context.Background(), "first", map[string]interface{}{})
+	log.Info(context.Background(), "second", map[string]interface{}{})
+	assert.Equal(t, 2, log.Stats().Buffered)
+
+	close(out.gate)
+	require.NoError(t, log.Shutdown(context.Background()))
+	assert.Equal(t, contract.LogStats{}, log.Stats())
+	assert.Contains(t, out.buf.String(), "first")
+	assert.Contains(t, out.buf.String(), "second")
+}
+
+func TestLog_AsyncSetOutput(t *testing.T) {
+	first, second := newGateWriter(), newGateWriter()
+	close(first.gate)
+	close(second.gate)
+	log := &ConsoleLog{}
+	log.c = framework.NewContainer()
+	log.SetLevel(contract.InfoLevel)
+	log.SetAsync(AsyncOptions{BufferSize: 10, FlushInterval: time.Hour})
+	log.SetOutput(first)
+
+	log.Info(context.Background(), "first", map[string]interface{}{})
+	assert.Equal(t, 1, log.Stats().Buffered)
+	old := log.asyncWriters[0]
+
+	// 替换output的时候写完原来的缓冲区, 并且停止原来的异步writer
+	log.SetOutput(second)
+	assert.Contains(t, first.buf.String(), "first")
+	assert.Len(t, log.asyncWriters, 1)
+	assert.NotSame(t, old, log.asyncWriters[0])
+	select {
+	case <-old.done:
+	default:
+		t.Fatal("replaced async writer not stopped")
+	}
+
+	log.Info(context.Background(), "second", map[string]interface{}{})
+	require.NoError(t, log.Shutdown(context.Background()))
+	assert.NotContains(t, first.buf.String(), "second")
+	assert.Contains(t, second.buf.String(), "second")
+}
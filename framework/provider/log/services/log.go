@@ -7,32 +7,78 @@ import (
 	"goweb/framework/provider/log/formatter"
 	"io"
 	pkgLog "log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Log struct {
+	// writeErrors 同步写入输出失败的次数, 放在第一个字段保证原子操作的对齐
+	writeErrors uint64
+
 	// 五个必要参数
 	level      contract.LogLevel   // 日志级别
 	formatter  contract.Formatter  // 日志格式化方法
 	ctxFielder contract.CtxFielder // ctx获取上下文字段
 	output     io.Writer           // 输出
 	c          framework.Container // 容器
+
+	// sinks multi驱动的多个输出, 不为空的时候日志分发到每个sink, 不再使用output
+	sinks []*logSink
+
+	// async 异步写入的配置, nil表示同步写入
+	async *AsyncOptions
+	// asyncWriters 创建的所有异步writer, Shutdown的时候写入缓冲区中剩余的日志
+	asyncWriters []*AsyncWriter
+
+	// caller 是否在日志中记录调用的文件和行号
+	caller bool
+	// sampler 日志采样, nil表示不采样
+	sampler *sampler
+
+	// lock 保护level, formatter, output, sinks和caller, 配置文件变化的时候会在其他goroutine中修改
+	lock sync.RWMutex
 }
 
-// IsLevelEnable 判断这个级别是否可以打印
+// IsLevelEnable 判断这个级别是否可以打印, 有多个sink的时候任意一个sink可以打印即可
 func (log *Log) IsLevelEnable(level contract.LogLevel) bool {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	if len(log.sinks) > 0 {
+		for _, sink := range log.sinks {
+			if sink.enable(level, log.level) {
+				return true
+			}
+		}
+		return false
+	}
 	return level <= log.level
 }
 
-// logf 为打印日志的核心函数
-func (log *Log) logf(level contract.LogLevel, ctx context.Context, msg string, fields map[string]interface{}) error {
+// logf 为打印日志的核心函数, module和base为Named和With设置的模块名和固定字段
+// 调用方传入的fields不会被修改, 可以为nil
+func (log *Log) logf(level contract.LogLevel, ctx context.Context, module string, base map[string]interface{}, msg string, fields map[string]interface{}) error {
 	// 先判断日志级别
 	if !log.IsLevelEnable(level) {
 		return nil
 	}
 
+	// 合并到新的map中, 优先级: 固定字段 < 调用传入的字段 < context中的字段 < trace信息
+	fs := make(map[string]interface{}, len(base)+len(fields)+2)
+	for k, v := range base {
+		fs[k] = v
+	}
+	for k, v := range fields {
+		fs[k] = v
+	}
+	if module != "" {
+		fs[formatter.FieldModule] = module
+	}
+
 	// 使用ctxFielder 获取context中的信息
-	fs := fields
 	if log.ctxFielder != nil {
 		t := log.ctxFielder(ctx)
 		if t != nil {
@@ -44,7 +90,7 @@ func (log *Log) logf(level contract.LogLevel, ctx context.Context, msg string, f
 
 	// 如果绑定了trace服务，获取trace信息
 	if log.c.IsBind(contract.TraceKey) {
-		tracer := log.c.MustMake(contract.TraceKey).(contract.Trace)
+		tracer := framework.MustMake[contract.Trace](log.c)
 		tc := tracer.GetTrace(ctx)
 		if tc != nil {
 			maps := tracer.ToMap(tc)
@@ -54,11 +100,47 @@ func (log *Log) logf(level contract.LogLevel, ctx context.Context, msg string, f
 		}
 	}
 
+	// 采样, 同样的日志在一个周期内超过数量之后只输出一部分
+	log.lock.RLock()
+	sampler := log.sampler
+	withCaller := log.caller
+	log.lock.RUnlock()
+	if sampler != nil && !sampler.allow(level, msg, fs) {
+		return nil
+	}
+
+	if withCaller {
+		// 0为logf, 1为Info等方法, 2为调用日志的位置
+		if _, file, line, ok := runtime.Caller(2); ok {
+			fs[formatter.FieldCaller] = trimCallerPath(file) + ":" + strconv.Itoa(line)
+		}
+	}
+	return log.write(level, msg, fs)
+}
+
+// write 将一条日志按照formatter序列化之后输出, 不再判断级别和采样
+func (log *Log) write(level contract.LogLevel, msg string, fs map[string]interface{}) error {
 	// 将日志信息按照formatter序列化为字符串
-	if log.formatter == nil {
-		log.formatter = formatter.TextFormatter
+	log.lock.RLock()
+	format := log.formatter
+	defaultLevel := log.level
+	sinks := log.sinks
+	output := log.output
+	log.lock.RUnlock()
+	if format == nil {
+		format = formatter.TextFormatter
+	}
+	// multi驱动分发到每个sink, 每个sink使用自己的级别和格式
+	if len(sinks) > 0 {
+		if failed := writeSinks(sinks, level, defaultLevel, format, time.Now(), msg, fs); failed > 0 {
+			atomic.AddUint64(&log.writeErrors, uint64(failed))
+		}
+		if level == contract.PanicLevel {
+			pkgLog.Panicln(msg)
+		}
+		return nil
 	}
-	ct, err := log.formatter(level, time.Now(), msg, fs)
+	ct, err := format(level, time.Now(), msg, fs)
 	if err != nil {
 		return err
 	}
@@ -69,55 +151,186 @@ func (log *Log) logf(level contract.LogLevel, ctx context.Context, msg string, f
 		return nil
 	}
 
-	// 通过output进行输出
-	log.output.Write(ct)
-	log.output.Write([]byte("\r\n"))
+	// 通过output进行输出, 一次写入整行, 异步模式下只是放入缓冲区
+	if _, err := output.Write(append(ct, '\r', '\n')); err != nil {
+		atomic.AddUint64(&log.writeErrors, 1)
+		return err
+	}
 	return nil
 
 }
 
-// SetOutput 设置output
+// SetOutput 设置output, 异步模式下使用新的异步writer包装
+// multi驱动设置output之后不再使用sinks, 所有日志输出到output
+// 被替换的异步writer会写完缓冲区中的日志之后停止
 func (log *Log) SetOutput(output io.Writer) {
-	log.output = output
+	log.lock.Lock()
+	replaced := []io.Writer{log.output}
+	for _, sink := range log.sinks {
+		replaced = append(replaced, sink.output)
+	}
+	retired := log.removeAsyncWriters(replaced)
+	log.sinks = nil
+	log.output = log.wrapOutput(output)
+	log.lock.Unlock()
+
+	// 不持有锁等待写入, 避免阻塞其他写日志的goroutine, 正在使用旧writer的goroutine会直接写入原来的输出
+	for _, w := range retired {
+		w.Close(context.Background())
+	}
+}
+
+// removeAsyncWriters 从asyncWriters中移除outputs中的异步writer并且返回, 调用的时候需要持有锁
+func (log *Log) removeAsyncWriters(outputs []io.Writer) []*AsyncWriter {
+	removed := []*AsyncWriter{}
+	writers := make([]*AsyncWriter, 0, len(log.asyncWriters))
+	for _, w := range log.asyncWriters {
+		matched := false
+		for _, output := range outputs {
+			if output == io.Writer(w) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			removed = append(removed, w)
+			continue
+		}
+		writers = append(writers, w)
+	}
+	log.asyncWriters = writers
+	return removed
+}
+
+// SetCaller 设置是否在日志中记录调用的文件和行号, 例如: demo/api.go:25
+func (log *Log) SetCaller(enable bool) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	log.caller = enable
+}
+
+// trimCallerPath 只保留文件所在的目录和文件名
+func trimCallerPath(file string) string {
+	index := strings.LastIndexByte(file, '/')
+	if index < 0 {
+		return file
+	}
+	if prev := strings.LastIndexByte(file[:index], '/'); prev >= 0 {
+		return file[prev+1:]
+	}
+	return file
+}
+
+// SetAsync 开启异步写入, 当前的输出和之后设置的输出都使用异步writer包装
+// multi驱动的每个sink使用各自的缓冲区, 慢的输出不影响其他输出
+func (log *Log) SetAsync(options AsyncOptions) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	if log.async != nil {
+		return
+	}
+	log.async = &options
+	if log.output != nil {
+		log.output = log.wrapOutput(log.output)
+	}
+	// 替换为新的sink, 正在写日志的goroutine仍然可以使用原来的sink
+	sinks := make([]*logSink, 0, len(log.sinks))
+	for _, sink := range log.sinks {
+		sinks = append(sinks, &logSink{
+			name:      sink.name,
+			level:     sink.level,
+			formatter: sink.formatter,
+			output:    log.wrapOutput(sink.output),
+			close:     sink.close,
+		})
+	}
+	if len(sinks) > 0 {
+		log.sinks = sinks
+	}
+}
+
+// wrapOutput 异步模式下使用异步writer包装输出, 调用的时候需要持有锁
+func (log *Log) wrapOutput(output io.Writer) io.Writer {
+	if log.async == nil || output == nil {
+		return output
+	}
+	w := NewAsyncWriter(output, *log.async)
+	log.asyncWriters = append(log.asyncWriters, w)
+	return w
+}
+
+// Stats 获取缓冲, 丢弃和写入失败的日志条数
+func (log *Log) Stats() contract.LogStats {
+	log.lock.RLock()
+	writers := log.asyncWriters
+	log.lock.RUnlock()
+	stats := contract.LogStats{WriteErrors: atomic.LoadUint64(&log.writeErrors)}
+	for _, w := range writers {
+		stats.Buffered += w.Buffered()
+		stats.Dropped += w.Dropped()
+		stats.WriteErrors += w.WriteErrors()
+	}
+	return stats
+}
+
+// Shutdown 输出最后一个采样周期的汇总, 异步模式下等待缓冲区中的日志全部写入
+func (log *Log) Shutdown(ctx context.Context) error {
+	log.lock.Lock()
+	sampler := log.sampler
+	log.sampler = nil
+	writers := log.asyncWriters
+	log.lock.Unlock()
+	if sampler != nil {
+		sampler.stop()
+	}
+	var firstErr error
+	for _, w := range writers {
+		if err := w.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 // Panic 输出panic的日志信息
 func (log *Log) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.PanicLevel, ctx, msg, fields)
+	log.logf(contract.PanicLevel, ctx, "", nil, msg, fields)
 }
 
 // Fatal will add fatal record which contains msg and fields
 func (log *Log) Fatal(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.FatalLevel, ctx, msg, fields)
+	log.logf(contract.FatalLevel, ctx, "", nil, msg, fields)
 }
 
 // Error will add error record which contains msg and fields
 func (log *Log) Error(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.ErrorLevel, ctx, msg, fields)
+	log.logf(contract.ErrorLevel, ctx, "", nil, msg, fields)
 }
 
 // Warn will add warn record which contains msg and fields
 func (log *Log) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.WarnLevel, ctx, msg, fields)
+	log.logf(contract.WarnLevel, ctx, "", nil, msg, fields)
 }
 
 // Info 会打印出普通的日志信息
 func (log *Log) Info(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.InfoLevel, ctx, msg, fields)
+	log.logf(contract.InfoLevel, ctx, "", nil, msg, fields)
 }
 
 // Debug will add debug record which contains msg and fields
 func (log *Log) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.DebugLevel, ctx, msg, fields)
+	log.logf(contract.DebugLevel, ctx, "", nil, msg, fields)
 }
 
 // Trace will add trace info which contains msg and fields
 func (log *Log) Trace(ctx context.Context, msg string, fields map[string]interface{}) {
-	log.logf(contract.TraceLevel, ctx, msg, fields)
+	log.logf(contract.TraceLevel, ctx, "", nil, msg, fields)
 }
 
 // SetLevel set log level, and higher level will be recorded
 func (log *Log) SetLevel(level contract.LogLevel) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
 	log.level = level
 }
 
@@ -128,5 +341,7 @@ func (log *Log) SetCtxFielder(handler contract.CtxFielder) {
 
 // SetFormatter will set formatter handler will covert data to string for recording
 func (log *Log) SetFormatter(formatter contract.Formatter) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
 	log.formatter = formatter
 }
\ No newline at end of file
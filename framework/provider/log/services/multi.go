@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"io"
+	pkgLog "log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LogSinkConfig multi驱动中一个输出的配置, 对应log.yaml中log.sinks的一项
+type LogSinkConfig struct {
+	// Driver 输出的驱动, 支持 console/single/rotate/custom
+	Driver string
+	// Level 输出的日志级别, UnknownLevel表示使用日志服务的级别
+	Level contract.LogLevel
+	// Formatter 输出的格式, nil表示使用日志服务的格式
+	Formatter contract.Formatter
+	// Options 驱动的配置项, 例如: folder, file, rotate_count, 和单个驱动时log下的配置项相同
+	Options map[string]interface{}
+}
+
+// logSink 一个正在使用的输出
+type logSink struct {
+	name      string
+	level     contract.LogLevel
+	formatter contract.Formatter
+	output    io.Writer
+	close     func() error
+
+	failed int32 // 上一次输出是否失败, 只在失败和恢复的时候打印提示, 避免刷屏
+}
+
+// enable 判断这个输出是否打印某个级别的日志, 没有设置级别的时候使用日志服务的级别
+func (sink *logSink) enable(level contract.LogLevel, defaultLevel contract.LogLevel) bool {
+	if sink.level == contract.UnknownLevel {
+		return level <= defaultLevel
+	}
+	return level <= sink.level
+}
+
+// write 格式化并输出一条日志, formatter或者writer出现panic的时候也当作失败处理
+func (sink *logSink) write(level contract.LogLevel, format contract.Formatter, t time.Time, msg string, fields map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if sink.formatter != nil {
+		format = sink.formatter
+	}
+	// formatter可能修改fields, 比如json格式会加入msg和level, 每个sink使用一份副本
+	fs := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		fs[k] = v
+	}
+	ct, err := format(level, t, msg, fs)
+	if err != nil {
+		return err
+	}
+	// 一次写入整行, 避免并发的时候和其他日志交错
+	_, err = sink.output.Write(append(ct, '\r', '\n'))
+	return err
+}
+
+// writeSinks 将一条日志分发到所有打印这个级别的输出, 某个输出失败不影响其他输出, 返回失败的输出个数
+func writeSinks(sinks []*logSink, level contract.LogLevel, defaultLevel contract.LogLevel, format contract.Formatter,
+	t time.Time, msg string, fields map[string]interface{}) int {
+	failed := 0
+	for _, sink := range sinks {
+		if !sink.enable(level, defaultLevel) {
+			continue
+		}
+		if err := sink.write(level, format, t, msg, fields); err != nil {
+			failed++
+			if atomic.CompareAndSwapInt32(&sink.failed, 0, 1) {
+				pkgLog.Println("log sink", sink.name, "error:", err)
+			}
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&sink.failed, 1, 0) {
+			pkgLog.Println("log sink", sink.name, "recovered")
+		}
+	}
+	return failed
+}
+
+// MultiLog 同时输出到多个地方的日志, 每个输出有自己的驱动, 级别和格式
+// 例如: 错误日志输出到控制台, 所有日志写入切割日志文件
+type MultiLog struct {
+	Log
+
+	// opened 打开的所有输出, SetOutput替换之后仍然需要在Shutdown的时候关闭
+	opened []*logSink
+}
+
+// NewMultiLog 实例化MultiLog, 参数顺序: container, level, ctxFielder, formatter, output, []LogSinkConfig
+// output只在custom驱动的输出中使用
+func NewMultiLog(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	level := params[1].(contract.LogLevel)
+	ctxFielder := params[2].(contract.CtxFielder)
+	formatter := params[3].(contract.Formatter)
+	output, _ := params[4].(io.Writer)
+	var configs []LogSinkConfig
+	if len(params) > 5 {
+		configs, _ = params[5].([]LogSinkConfig)
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("log driver multi needs at least one sink in log.sinks")
+	}
+
+	log := &MultiLog{}
+	log.SetLevel(level)
+	log.SetCtxFielder(ctxFielder)
+	log.SetFormatter(formatter)
+	log.c = c
+
+	sinks := make([]*logSink, 0, len(configs))
+	for i, config := range configs {
+		sink, err := newLogSink(c, config, output)
+		if err != nil {
+			// 关闭已经打开的输出
+			closeSinks(sinks)
+			return nil, fmt.Errorf("log.sinks[%d]: %w", i, err)
+		}
+		sink.name = fmt.Sprintf("%d(%s)", i, config.Driver)
+		sinks = append(sinks, sink)
+	}
+	log.sinks = sinks
+	log.opened = sinks
+	return log, nil
+}
+
+// newLogSink 根据驱动创建一个输出
+func newLogSink(c framework.Container, config LogSinkConfig, output io.Writer) (*logSink, error) {
+	sink := &logSink{level: config.Level, formatter: config.Formatter}
+	switch strings.ToLower(config.Driver) {
+	case "console":
+		sink.output = os.Stdout
+	case "single":
+		fd, _, _, err := openSingleFile(c, config.Options)
+		if err != nil {
+			return nil, err
+		}
+		sink.output = fd
+		sink.close = func() error {
+			if err := fd.Sync(); err != nil {
+				fd.Close()
+				return err
+			}
+			return fd.Close()
+		}
+	case "rotate":
+		w, _, _, err := newRotateWriter(c, config.Options)
+		if err != nil {
+			return nil, err
+		}
+		sink.output = w
+		sink.close = w.Close
+	case "custom":
+		if output == nil {
+			return nil, errors.New("custom sink needs the Output of LogServiceProvider")
+		}
+		sink.output = output
+	default:
+		return nil, errors.New("unsupported sink driver " + config.Driver)
+	}
+	return sink, nil
+}
+
+// closeSinks 关闭所有的输出, 返回第一个错误
+func closeSinks(sinks []*logSink) error {
+	var firstErr error
+	for _, sink := range sinks {
+		if sink.close == nil {
+			continue
+		}
+		if err := sink.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Shutdown 写入异步缓冲区中剩余的日志, 然后关闭所有的日志文件
+func (log *MultiLog) Shutdown(ctx context.Context) error {
+	flushErr := log.Log.Shutdown(ctx)
+	if err := closeSinks(log.opened); err != nil {
+		return err
+	}
+	return flushErr
+}
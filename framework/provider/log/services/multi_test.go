@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/log/formatter"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failWriter 总是写入失败的输出
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// panicWriter 写入时panic的输出
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) {
+	panic("broken writer")
+}
+
+func TestMultiLog(t *testing.T) {
+	errorOut := &bytes.Buffer{}
+	allOut := &bytes.Buffer{}
+	log := &MultiLog{}
+	log.c = framework.NewContainer()
+	log.SetLevel(contract.InfoLevel)
+	log.SetFormatter(formatter.TextFormatter)
+	log.sinks = []*logSink{
+		{name: "fail", output: failWriter{}},
+		{name: "panic", output: panicWriter{}},
+		{name: "all", level: contract.TraceLevel, formatter: formatter.JsonFormatter, output: allOut},
+		{name: "error", level: contract.ErrorLevel, output: errorOut},
+	}
+	ctx := context.Background()
+
+	assert.True(t, log.IsLevelEnable(contract.TraceLevel))
+	log.Error(ctx, "error message", map[string]interface{}{"id": 1})
+	log.Debug(ctx, "debug message", map[string]interface{}{})
+
+	assert.Contains(t, errorOut.String(), "error message")
+	assert.NotContains(t, errorOut.String(), "timestamp")
+	assert.NotContains(t, errorOut.String(), "debug message")
+	lines := strings.Split(strings.TrimSpace(allOut.String()), "\r\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"msg":"error message"`)
+	assert.Contains(t, lines[1], `"msg":"debug message"`)
+
+	// 没有设置级别的sink使用日志服务的级别
+	log.sinks = []*logSink{{output: errorOut}}
+	errorOut.Reset()
+	log.Debug(ctx, "debug message", map[string]interface{}{})
+	assert.False(t, log.IsLevelEnable(contract.DebugLevel))
+	log.SetLevel(contract.DebugLevel)
+	log.Debug(ctx, "debug message", map[string]interface{}{})
+	assert.Equal(t, 1, strings.Count(errorOut.String(), "debug message"))
+}
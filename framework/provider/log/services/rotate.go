@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"goweb/framework"
 	"goweb/framework/contract"
@@ -11,6 +12,7 @@ import (
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 )
 
 // RotateLog 代表会进行切割的日志文件存储
@@ -21,6 +23,8 @@ type RotateLog struct {
 	folder string
 	// 日志文件名
 	file string
+	// 切割日志的writer
+	writer *rotatelogs.RotateLogs
 }
 
 // NewRotateLog 实例化RotateLog
@@ -31,13 +35,35 @@ func NewRotateLog(params ...interface{}) (interface{}, error) {
 	ctxFielder := params[2].(contract.CtxFielder)
 	formatter := params[3].(contract.Formatter)
 
-	appService := c.MustMake(contract.AppKey).(contract.App)
-	configService := c.MustMake(contract.ConfigKey).(contract.Config)
+	configService := framework.MustMake[contract.Config](c)
+
+	// 设置基础信息
+	log := &RotateLog{}
+	log.SetLevel(level)
+	log.SetCtxFielder(ctxFielder)
+	log.SetFormatter(formatter)
+
+	w, folder, file, err := newRotateWriter(c, configService.GetStringMap("log"))
+	if err != nil {
+		return nil, err
+	}
+	log.folder = folder
+	log.file = file
+	log.SetOutput(w)
+	log.writer = w
+	log.c = c
+	return log, nil
+}
+
+// newRotateWriter 按照配置项创建切割日志的writer, 配置项包括folder, file, date_format,
+// rotate_count, rotate_size, max_age, rotate_time
+func newRotateWriter(c framework.Container, options map[string]interface{}) (*rotatelogs.RotateLogs, string, string, error) {
+	appService := framework.MustMake[contract.App](c)
 
 	// 从配置文件中获取folder信息，否则使用默认的LogFolder文件夹
 	folder := appService.LogFolder()
-	if configService.IsExist("log.folder") {
-		folder = configService.GetString("log.folder")
+	if val, ok := options["folder"]; ok {
+		folder = cast.ToString(val)
 	}
 	// 如果folder不存在，则创建
 	if !util.Exists(folder) {
@@ -46,58 +72,60 @@ func NewRotateLog(params ...interface{}) (interface{}, error) {
 
 	// 从配置文件中获取file信息，否则使用默认的hade.log
 	file := "goweb.log"
-	if configService.IsExist("log.file") {
-		file = configService.GetString("log.file")
+	if val, ok := options["file"]; ok {
+		file = cast.ToString(val)
 	}
 
 	// 从配置文件获取date_format信息
 	dateFormat := "%Y%m%d%H"
-	if configService.IsExist("log.date_format") {
-		dateFormat = configService.GetString("log.date_format")
+	if val, ok := options["date_format"]; ok {
+		dateFormat = cast.ToString(val)
 	}
 
 	linkName := rotatelogs.WithLinkName(filepath.Join(folder, file))
-	options := []rotatelogs.Option{linkName}
+	rotateOptions := []rotatelogs.Option{linkName}
 
 	// 从配置文件获取rotate_count信息
-	if configService.IsExist("log.rotate_count") {
-		rotateCount := configService.GetInt("log.rotate_count")
-		options = append(options, rotatelogs.WithRotationCount(uint(rotateCount)))
+	if val, ok := options["rotate_count"]; ok {
+		rotateCount := cast.ToInt(val)
+		rotateOptions = append(rotateOptions, rotatelogs.WithRotationCount(uint(rotateCount)))
 	}
 
 	// 从配置文件获取rotate_size信息
-	if configService.IsExist("log.rotate_size") {
-		rotateSize := configService.GetInt("log.rotate_size")
-		options = append(options, rotatelogs.WithRotationSize(int64(rotateSize)))
+	if val, ok := options["rotate_size"]; ok {
+		rotateSize := cast.ToInt(val)
+		rotateOptions = append(rotateOptions, rotatelogs.WithRotationSize(int64(rotateSize)))
 	}
 
 	// 从配置文件获取max_age信息
-	if configService.IsExist("log.max_age") {
-		if maxAgeParse, err := time.ParseDuration(configService.GetString("log.max_age")); err == nil {
-			options = append(options, rotatelogs.WithMaxAge(maxAgeParse))
+	if val, ok := options["max_age"]; ok {
+		if maxAgeParse, err := time.ParseDuration(cast.ToString(val)); err == nil {
+			rotateOptions = append(rotateOptions, rotatelogs.WithMaxAge(maxAgeParse))
 		}
 	}
 
 	// 从配置文件获取rotate_time信息
-	if configService.IsExist("log.rotate_time") {
-		if rotateTimeParse, err := time.ParseDuration(configService.GetString("log.rotate_time")); err == nil {
-			options = append(options, rotatelogs.WithRotationTime(rotateTimeParse))
+	if val, ok := options["rotate_time"]; ok {
+		if rotateTimeParse, err := time.ParseDuration(cast.ToString(val)); err == nil {
+			rotateOptions = append(rotateOptions, rotatelogs.WithRotationTime(rotateTimeParse))
 		}
 	}
 
-	// 设置基础信息
-	log := &RotateLog{}
-	log.SetLevel(level)
-	log.SetCtxFielder(ctxFielder)
-	log.SetFormatter(formatter)
-	log.folder = folder
-	log.file = file
-
-	w, err := rotatelogs.New(fmt.Sprintf("%s.%s", filepath.Join(log.folder, log.file), dateFormat), options...)
+	w, err := rotatelogs.New(fmt.Sprintf("%s.%s", filepath.Join(folder, file), dateFormat), rotateOptions...)
 	if err != nil {
-		return nil, errors.Wrap(err, "new rotatelogs error")
+		return nil, "", "", errors.Wrap(err, "new rotatelogs error")
 	}
-	log.SetOutput(w)
-	log.c = c
-	return log, nil
-}
\ No newline at end of file
+	return w, folder, file, nil
+}
+
+// Shutdown 关闭当前的日志文件
+func (log *RotateLog) Shutdown(ctx context.Context) error {
+	// 先写入异步缓冲区中剩余的日志
+	if err := log.Log.Shutdown(ctx); err != nil {
+		return err
+	}
+	if log.writer == nil {
+		return nil
+	}
+	return log.writer.Close()
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"goweb/framework/contract"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSamplingInterval   = time.Second
+	defaultSamplingFirst      = 100
+	defaultSamplingThereafter = 100
+)
+
+// SamplingOptions 日志采样的配置, 对应log.yaml中的log.sampling
+// 每个周期内同一个key的日志先全部输出First条, 之后每Thereafter条输出1条
+type SamplingOptions struct {
+	// Interval 采样的周期, 默认1s
+	Interval time.Duration
+	// First 每个周期内全部输出的条数, 默认100
+	First int
+	// Thereafter 超过First之后每多少条输出1条, 默认100, 小于0表示超过First之后全部丢弃
+	Thereafter int
+	// Key 按照某个字段的值分组, 例如: path, 日志中没有这个字段的时候按照级别和msg分组
+	// 为空的时候按照级别和msg分组
+	Key string
+	// Level 只对这个级别以及更详细的日志采样, 默认info, 即warn和error等日志不会被丢弃
+	Level contract.LogLevel
+}
+
+// sampleCounter 一个key在当前周期内的计数
+type sampleCounter struct {
+	level      contract.LogLevel
+	msg        string
+	field      interface{} // 按照字段分组的时候字段的值
+	count      int
+	suppressed int
+}
+
+// sampler 按照key统计每个周期内的日志条数, 周期结束的时候输出被丢弃的条数
+type sampler struct {
+	options SamplingOptions
+	emit    func(level contract.LogLevel, msg string, fields map[string]interface{}) error
+
+	lock     sync.Mutex
+	counters map[string]*sampleCounter
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// newSampler 创建采样器并且启动周期统计的goroutine, emit用于输出汇总的日志
+func newSampler(options SamplingOptions, emit func(level contract.LogLevel, msg string, fields map[string]interface{}) error) *sampler {
+	if options.Interval <= 0 {
+		options.Interval = defaultSamplingInterval
+	}
+	if options.First <= 0 {
+		options.First = defaultSamplingFirst
+	}
+	if options.Thereafter == 0 {
+		options.Thereafter = defaultSamplingThereafter
+	}
+	if options.Level == contract.UnknownLevel {
+		options.Level = contract.InfoLevel
+	}
+	s := &sampler{
+		options:  options,
+		emit:     emit,
+		counters: map[string]*sampleCounter{},
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// allow 判断这条日志是否输出, 不输出的时候记录到被丢弃的条数中
+func (s *sampler) allow(level contract.LogLevel, msg string, fields map[string]interface{}) bool {
+	// 级别比配置的更严重的日志不采样, 比如warn, error
+	if level < s.options.Level {
+		return true
+	}
+	key := fmt.Sprintf("%d|msg|%s", level, msg)
+	var field interface{}
+	if s.options.Key != "" {
+		if val, ok := fields[s.options.Key]; ok {
+			field = val
+			key = fmt.Sprintf("%d|field|%v", level, val)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &sampleCounter{level: level, msg: msg, field: field}
+		s.counters[key] = counter
+	}
+	counter.count++
+	if counter.count <= s.options.First {
+		return true
+	}
+	if s.options.Thereafter > 0 && (counter.count-s.options.First)%s.options.Thereafter == 0 {
+		return true
+	}
+	counter.suppressed++
+	return false
+}
+
+// run 每个周期结束的时候重新计数, 并且输出汇总
+func (s *sampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			s.report()
+			return
+		case <-ticker.C:
+			s.report()
+		}
+	}
+}
+
+// report 输出当前周期内每个key被丢弃的条数, 然后清空计数
+func (s *sampler) report() {
+	s.lock.Lock()
+	counters := s.counters
+	s.counters = map[string]*sampleCounter{}
+	s.lock.Unlock()
+
+	keys := make([]string, 0, len(counters))
+	for key, counter := range counters {
+		if counter.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		counter := counters[key]
+		fields := map[string]interface{}{
+			"sampled_msg": counter.msg,
+			"suppressed":  counter.suppressed,
+			"total":       counter.count,
+			"interval":    s.options.Interval.String(),
+		}
+		if counter.field != nil {
+			fields[s.options.Key] = counter.field
+		}
+		s.emit(counter.level, "log sampling suppressed entries", fields)
+	}
+}
+
+// stop 停止统计, 输出最后一个周期的汇总
+func (s *sampler) stop() {
+	close(s.stopCh)
+	<-s.done
+}
+
+// SetSampling 开启日志采样, 同样的日志在一个周期内超过数量之后只输出一部分
+// 所有驱动都会经过采样, 周期结束的时候输出一条汇总日志, 记录被丢弃的条数
+func (log *Log) SetSampling(options SamplingOptions) {
+	log.setSampler(newSampler(options, log.write))
+}
+
+// DisableSampling 关闭日志采样, 输出当前周期的汇总
+func (log *Log) DisableSampling() {
+	log.setSampler(nil)
+}
+
+// setSampler 替换采样器, 停止原来的采样器
+func (log *Log) setSampler(s *sampler) {
+	log.lock.Lock()
+	old := log.sampler
+	log.sampler = s
+	log.lock.Unlock()
+	if old != nil {
+		old.stop()
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"goweb/framework/contract"
+	"goweb/framework/provider/log/formatter"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedBuffer 可以并发读写的buffer, 采样的汇总在后台goroutine中输出
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+// jsonLines 解析每一行json格式的日志
+func jsonLines(t *testing.T, out fmt.Stringer) []map[string]interface{} {
+	ret := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\r\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+func TestLog_Sampling(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := newTestLog(out, formatter.JsonFormatter)
+	log.SetLevel(contract.DebugLevel)
+	log.SetSampling(SamplingOptions{Interval: time.Hour, First: 2, Thereafter: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		log.Info(ctx, "hot", map[string]interface{}{"i": i})
+		log.Warn(ctx, "warn", nil)
+	}
+	log.Debug(ctx, "hot", nil)
+
+	entries := jsonLines(t, out)
+	hot := []float64{}
+	warn := 0
+	for _, entry := range entries {
+		switch entry["msg"] {
+		case "hot":
+			if entry["level"] == float64(contract.InfoLevel) {
+				hot = append(hot, entry["i"].(float64))
+			}
+		case "warn":
+			warn++
+		}
+	}
+	// 前2条全部输出, 之后每3条输出1条
+	assert.Equal(t, []float64{0, 1, 4, 7}, hot)
+	// warn比info严重, 不采样
+	assert.Equal(t, 10, warn)
+	// debug和info的key不同
+	assert.Len(t, entries, 15)
+
+	out.Reset()
+	require.NoError(t, log.Shutdown(context.Background()))
+	entries = jsonLines(t, out)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "log sampling suppressed entries", entries[0]["msg"])
+	assert.Equal(t, "hot", entries[0]["sampled_msg"])
+	assert.Equal(t, float64(6), entries[0]["suppressed"])
+	assert.Equal(t, float64(10), entries[0]["total"])
+	assert.Equal(t, float64(contract.InfoLevel), entries[0]["level"])
+}
+
+func TestLog_SamplingByField(t *testing.T) {
+	out := &lockedBuffer{}
+	log := newTestLog(&bytes.Buffer{}, formatter.JsonFormatter)
+	log.SetOutput(out)
+	log.SetSampling(SamplingOptions{Interval: 100 * time.Millisecond, First: 1, Thereafter: -1, Key: "path"})
+	ctx := context.Background()
+
+	// 不同的msg, 同样的path分为一组
+	log.Info(ctx, "a", map[string]interface{}{"path": "/users"})
+	log.Info(ctx, "b", map[string]interface{}{"path": "/users"})
+	log.With(map[string]interface{}{"path": "/users"}).Info(ctx, "c", nil)
+	log.Info(ctx, "d", map[string]interface{}{"path": "/orders"})
+
+	// 周期结束的时候输出汇总, 之后重新计数
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "suppressed")
+	}, time.Second, 5*time.Millisecond)
+	log.DisableSampling()
+
+	entries := jsonLines(t, out)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "a", entries[0]["msg"])
+	assert.Equal(t, "d", entries[1]["msg"])
+	assert.Equal(t, "/users", entries[2]["path"])
+	assert.Equal(t, float64(2), entries[2]["suppressed"])
+}
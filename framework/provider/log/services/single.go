@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"goweb/framework"
 	"goweb/framework/contract"
 	"goweb/framework/util"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 )
 
 type SingleLog struct {
@@ -25,35 +27,62 @@ func NewSingleLog(params ...interface{}) (interface{}, error) {
 	ctxFielder := params[2].(contract.CtxFielder)
 	formatter := params[3].(contract.Formatter)
 
-	appService := c.MustMake(contract.AppKey).(contract.App)
-	configService := c.MustMake(contract.ConfigKey).(contract.Config)
+	configService := framework.MustMake[contract.Config](c)
 
 	log := &SingleLog{}
 	log.SetLevel(level)
 	log.SetCtxFielder(ctxFielder)
 	log.SetFormatter(formatter)
 
-	folder := appService.LogFolder()
-	if configService.IsExist("log.folder") {
-		folder = configService.GetString("log.folder")
+	fd, folder, file, err := openSingleFile(c, configService.GetStringMap("log"))
+	if err != nil {
+		return nil, err
 	}
 	log.folder = folder
+	log.file = file
+
+	log.SetOutput(fd)
+	log.fd = fd
+	log.c = c
+
+	return log, nil
+}
+
+// openSingleFile 按照配置项folder和file打开日志文件, 没有配置的时候使用默认的日志目录和goweb.log
+func openSingleFile(c framework.Container, options map[string]interface{}) (*os.File, string, string, error) {
+	appService := framework.MustMake[contract.App](c)
+
+	folder := appService.LogFolder()
+	if val, ok := options["folder"]; ok {
+		folder = cast.ToString(val)
+	}
 	if !util.Exists(folder) {
 		os.MkdirAll(folder, os.ModePerm)
 	}
 
-	log.file = "goweb.log"
-	if configService.IsExist("log.file") {
-		log.file = configService.GetString("log.file")
+	file := "goweb.log"
+	if val, ok := options["file"]; ok {
+		file = cast.ToString(val)
 	}
 
-	fd, err := os.OpenFile(filepath.Join(log.folder, log.file), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
+	fd, err := os.OpenFile(filepath.Join(folder, file), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
 	if err != nil {
-		return nil, errors.Wrap(err, "open log file err")
+		return nil, "", "", errors.Wrap(err, "open log file err")
 	}
+	return fd, folder, file, nil
+}
 
-	log.SetOutput(fd)
-	log.c = c
-
-	return log, nil
-}
\ No newline at end of file
+// Shutdown 将日志写入磁盘并关闭日志文件
+func (log *SingleLog) Shutdown(ctx context.Context) error {
+	// 先写入异步缓冲区中剩余的日志
+	if err := log.Log.Shutdown(ctx); err != nil {
+		return err
+	}
+	if log.fd == nil {
+		return nil
+	}
+	if err := log.fd.Sync(); err != nil {
+		return err
+	}
+	return log.fd.Close()
+}
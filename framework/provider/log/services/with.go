@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"goweb/framework/contract"
+	"io"
+)
+
+// childLog 由With和Named创建的日志, 带有固定字段和模块名
+// 和创建它的日志共享级别, 格式和输出, 配置文件变化的时候同样生效
+type childLog struct {
+	root   *Log
+	module string
+	fields map[string]interface{}
+}
+
+// With 返回带有固定字段的日志, 每条日志都会带上这些字段, 不修改当前日志和传入的fields
+func (log *Log) With(fields map[string]interface{}) contract.Log {
+	return &childLog{root: log, fields: mergeFields(nil, fields)}
+}
+
+// Named 返回指定模块名的日志, 模块名输出在module字段中
+func (log *Log) Named(module string) contract.Log {
+	return &childLog{root: log, module: module}
+}
+
+// mergeFields 合并到一个新的map中, 后面的字段覆盖前面的字段
+func mergeFields(base map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(base)+len(fields))
+	for k, v := range base {
+		ret[k] = v
+	}
+	for k, v := range fields {
+		ret[k] = v
+	}
+	return ret
+}
+
+// With 在当前的固定字段上追加字段, 同名的字段使用新的值
+func (log *childLog) With(fields map[string]interface{}) contract.Log {
+	return &childLog{root: log.root, module: log.module, fields: mergeFields(log.fields, fields)}
+}
+
+// Named 在当前的模块名后追加子模块名, 使用.连接, 例如: order.payment
+func (log *childLog) Named(module string) contract.Log {
+	if log.module != "" && module != "" {
+		module = log.module + "." + module
+	} else if module == "" {
+		module = log.module
+	}
+	return &childLog{root: log.root, module: module, fields: log.fields}
+}
+
+// Panic 输出panic的日志信息
+func (log *childLog) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.PanicLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Fatal 输出fatal的日志信息
+func (log *childLog) Fatal(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.FatalLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Error 输出error的日志信息
+func (log *childLog) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.ErrorLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Warn 输出warn的日志信息
+func (log *childLog) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.WarnLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Info 输出info的日志信息
+func (log *childLog) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.InfoLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Debug 输出debug的日志信息
+func (log *childLog) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.DebugLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// Trace 输出trace的日志信息
+func (log *childLog) Trace(ctx context.Context, msg string, fields map[string]interface{}) {
+	log.root.logf(contract.TraceLevel, ctx, log.module, log.fields, msg, fields)
+}
+
+// SetLevel 设置共享的日志级别, 同时影响创建它的日志
+func (log *childLog) SetLevel(level contract.LogLevel) {
+	log.root.SetLevel(level)
+}
+
+// SetCtxFielder 设置共享的ctxFielder, 同时影响创建它的日志
+func (log *childLog) SetCtxFielder(handler contract.CtxFielder) {
+	log.root.SetCtxFielder(handler)
+}
+
+// SetFormatter 设置共享的格式, 同时影响创建它的日志
+func (log *childLog) SetFormatter(formatter contract.Formatter) {
+	log.root.SetFormatter(formatter)
+}
+
+// SetOutput 设置共享的输出, 同时影响创建它的日志
+func (log *childLog) SetOutput(out io.Writer) {
+	log.root.SetOutput(out)
+}
+
+// Stats 获取共享的统计信息
+func (log *childLog) Stats() contract.LogStats {
+	return log.root.Stats()
+}
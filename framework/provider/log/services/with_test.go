@@ -0,0 +1,89 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/log/formatter"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLog(out *bytes.Buffer, format contract.Formatter) *ConsoleLog {
+	log := &ConsoleLog{}
+	log.c = framework.NewContainer()
+	log.SetLevel(contract.InfoLevel)
+	log.SetFormatter(format)
+	log.SetOutput(out)
+	return log
+}
+
+func TestLog_WithNamed(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := newTestLog(out, formatter.JsonFormatter)
+	log.SetCtxFielder(func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"request_id": "r1"}
+	})
+	ctx := context.Background()
+
+	// 传入nil不会panic, 传入的map不会被修改
+	log.Info(ctx, "nil fields", nil)
+	fields := map[string]interface{}{"id": 1}
+	log.Info(ctx, "fields", fields)
+	assert.Equal(t, map[string]interface{}{"id": 1}, fields)
+
+	base := map[string]interface{}{"user": "foo"}
+	order := log.Named("order").With(base)
+	base["user"] = "changed"
+	payment := order.Named("payment").With(map[string]interface{}{"amount": 10})
+	out.Reset()
+	payment.Info(ctx, "paid", map[string]interface{}{"amount": 20})
+	order.Info(ctx, "created", nil)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\r\n")
+	require.Len(t, lines, 2)
+	var first, second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "order.payment", first["module"])
+	assert.Equal(t, "foo", first["user"])
+	assert.Equal(t, float64(20), first["amount"])
+	assert.Equal(t, "r1", first["request_id"])
+	assert.Equal(t, "order", second["module"])
+	assert.NotContains(t, second, "amount")
+
+	// 子日志和父日志共享级别
+	payment.SetLevel(contract.ErrorLevel)
+	out.Reset()
+	log.Info(ctx, "hidden", nil)
+	order.Info(ctx, "hidden", nil)
+	assert.Empty(t, out.String())
+}
+
+func TestLog_Caller(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := newTestLog(out, formatter.TextFormatter)
+	ctx := context.Background()
+
+	log.Info(ctx, "no caller", nil)
+	assert.NotContains(t, out.String(), "with_test.go")
+
+	log.SetCaller(true)
+	out.Reset()
+	_, _, line, _ := runtime.Caller(0)
+	log.Info(ctx, "root", nil)
+	log.Named("order").Info(ctx, "child", map[string]interface{}{"id": 1})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\r\n")
+	require.Len(t, lines, 2)
+	caller := "services/with_test.go:" + strconv.Itoa(line+1)
+	assert.Contains(t, lines[0], "\t"+caller+"\t\"root\"")
+	assert.Contains(t, lines[1], "[order]\tservices/with_test.go:"+strconv.Itoa(line+2)+"\t\"child\"\tmap[id:1]")
+}
@@ -0,0 +1,76 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+
+	"gorm.io/gorm"
+)
+
+// GetBaseConfig 读取database.yaml根目录的通用配置
+func GetBaseConfig(c framework.Container) *contract.DBConfig {
+	configService := framework.MustMake[contract.Config](c)
+	logService := framework.MustMake[contract.Log](c)
+
+	config := &contract.DBConfig{
+		Protocol:  "tcp",
+		Charset:   "utf8mb4",
+		Collation: "utf8mb4_unicode_ci",
+		Loc:       "Local",
+		ParseTime: true,
+	}
+	// 直接使用配置服务的load方法读取,yaml文件
+	if err := configService.Load("database", config); err != nil {
+		logService.Error(context.Background(), "parse database config error", map[string]interface{}{"err": err})
+	}
+	return config
+}
+
+// WithConnection 表示使用database.yaml中某个名字的连接，比如 WithConnection("mysql") 读取 database.mysql
+func WithConnection(name string) contract.DBOption {
+	return WithConfigPath("database." + name)
+}
+
+// WithConfigPath 加载配置文件地址，会覆盖database.yaml中的通用配置
+func WithConfigPath(configPath string) contract.DBOption {
+	return func(container framework.Container, config *contract.DBConfig) error {
+		configService := framework.MustMake[contract.Config](container)
+		if !configService.IsExist(configPath) {
+			return errors.New("database config not exist: " + configPath)
+		}
+		// 加载configPath配置路径
+		if err := configService.Load(configPath, config); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// WithGormConfig 表示自行配置Gorm的配置信息
+func WithGormConfig(gormConfig func(options *gorm.Config)) contract.DBOption {
+	return func(container framework.Container, config *contract.DBConfig) error {
+		if config.Config == nil {
+			config.Config = &gorm.Config{}
+		}
+		gormConfig(config.Config)
+		return nil
+	}
+}
+
+// WithDryRun 设置空跑模式
+func WithDryRun() contract.DBOption {
+	return func(container framework.Container, config *contract.DBConfig) error {
+		config.DryRun = true
+		return nil
+	}
+}
+
+// WithFullSaveAssociations 设置保存时候关联
+func WithFullSaveAssociations() contract.DBOption {
+	return func(container framework.Container, config *contract.DBConfig) error {
+		config.FullSaveAssociations = true
+		return nil
+	}
+}
@@ -0,0 +1,184 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"sync"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// GormService 代表gorm的实现
+type GormService struct {
+	container framework.Container // 服务容器
+	dbs       map[string]*gorm.DB // key为dsn, value为gorm.DB（连接池）
+
+	lock *sync.RWMutex
+}
+
+// NewGormService 代表实例化gorm服务
+func NewGormService(params ...interface{}) (interface{}, error) {
+	if len(params) != 1 {
+		return nil, errors.New("param error")
+	}
+	container := params[0].(framework.Container)
+	dbs := make(map[string]*gorm.DB)
+	lock := &sync.RWMutex{}
+	return &GormService{
+		container: container,
+		dbs:       dbs,
+		lock:      lock,
+	}, nil
+}
+
+// GetDB 获取DB, 没有传递连接的时候使用database.default指定的连接
+func (app *GormService) GetDB(option ...contract.DBOption) (*gorm.DB, error) {
+	configService := framework.MustMake[contract.Config](app.container)
+	logService := framework.MustMake[contract.Log](app.container)
+
+	// 读取默认配置
+	config := GetBaseConfig(app.container)
+
+	// 设置Logger
+	ormLogger := NewOrmLogger(logService)
+	config.Config = &gorm.Config{
+		Logger: ormLogger,
+	}
+
+	// option对opt进行修改
+	for _, opt := range option {
+		if err := opt(app.container, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// 如果没有指定连接，使用默认连接
+	if config.Driver == "" {
+		name := configService.GetString("database.default")
+		if name == "" {
+			return nil, errors.New("database connection not set, please set database.default")
+		}
+		if err := WithConnection(name)(app.container, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// 如果最终的config没有设置dsn,就生成dsn
+	if config.Dsn == "" {
+		if config.Driver != "mysql" {
+			return nil, errors.New("database dsn not set, driver: " + config.Driver)
+		}
+		dsn, err := config.FormatDsn()
+		if err != nil {
+			return nil, err
+		}
+		config.Dsn = dsn
+	}
+
+	// 判断是否已经实例化了gorm.DB
+	app.lock.RLock()
+	if db, ok := app.dbs[config.Dsn]; ok {
+		app.lock.RUnlock()
+		return db, nil
+	}
+	app.lock.RUnlock()
+
+	// 没有实例化gorm.DB，那么就要进行实例化操作
+	app.lock.Lock()
+	defer app.lock.Unlock()
+
+	// 加锁之后再判断一次，防止并发时重复创建连接池
+	if db, ok := app.dbs[config.Dsn]; ok {
+		return db, nil
+	}
+
+	// 实例化gorm.DB
+	var db *gorm.DB
+	var err error
+	switch config.Driver {
+	case "mysql":
+		db, err = gorm.Open(mysql.Open(config.Dsn), config)
+	case "sqlite":
+		db, err = gorm.Open(sqlite.Open(config.Dsn), config)
+	default:
+		return nil, errors.New("database driver not support: " + config.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置对应的连接池配置
+	sqlDB, err := db.DB()
+	if err != nil {
+		return db, err
+	}
+
+	if config.ConnMaxIdle > 0 {
+		sqlDB.SetMaxIdleConns(config.ConnMaxIdle)
+	}
+	if config.ConnMaxOpen > 0 {
+		sqlDB.SetMaxOpenConns(config.ConnMaxOpen)
+	}
+	if config.ConnMaxLifetime != "" {
+		liftTime, err := time.ParseDuration(config.ConnMaxLifetime)
+		if err != nil {
+			logService.Error(context.Background(), "conn max lift time error", map[string]interface{}{
+				"err": err,
+			})
+		} else {
+			sqlDB.SetConnMaxLifetime(liftTime)
+		}
+	}
+
+	if config.ConnMaxIdletime != "" {
+		idleTime, err := time.ParseDuration(config.ConnMaxIdletime)
+		if err != nil {
+			logService.Error(context.Background(), "conn max idle time error", map[string]interface{}{
+				"err": err,
+			})
+		} else {
+			sqlDB.SetConnMaxIdleTime(idleTime)
+		}
+	}
+
+	// 挂载到map中，结束配置
+	app.dbs[config.Dsn] = db
+	return db, nil
+}
+
+// CanConnect 判断DB是否可以连接
+func (app *GormService) CanConnect(ctx context.Context, db *gorm.DB) (bool, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return false, err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Shutdown 关闭所有已经创建的连接池, 关闭之后清空缓存, 再次调用GetDB会重新创建连接池
+func (app *GormService) Shutdown(ctx context.Context) error {
+	app.lock.Lock()
+	dbs := app.dbs
+	app.dbs = make(map[string]*gorm.DB)
+	app.lock.Unlock()
+
+	var firstErr error
+	for _, db := range dbs {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
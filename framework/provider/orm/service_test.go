@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/app"
+	"goweb/framework/provider/config"
+	"goweb/framework/provider/env"
+	"goweb/framework/provider/log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type testUser struct {
+	ID   int64
+	Name string
+}
+
+// newTestContainer 使用临时目录下的database.yaml初始化一个容器
+func newTestContainer(t *testing.T) framework.Container {
+	baseFolder := t.TempDir()
+	configFolder := filepath.Join(baseFolder, "config", "testing")
+	require.NoError(t, os.MkdirAll(configFolder, os.ModePerm))
+
+	database := "default: sqlite\n" +
+		"conn_max_open: 1\n" +
+		"sqlite:\n" +
+		"  driver: sqlite\n" +
+		"  dsn: " + filepath.Join(baseFolder, "test.db") + "\n" +
+		"other:\n" +
+		"  driver: sqlite\n" +
+		"  dsn: " + filepath.Join(baseFolder, "other.db") + "\n" +
+		"unknown:\n" +
+		"  driver: foo\n" +
+		"  dsn: foo.db\n"
+	require.NoError(t, os.WriteFile(filepath.Join(configFolder, "database.yaml"), []byte(database), 0644))
+	t.Setenv("APP_ENV", contract.EnvTesting)
+
+	container := framework.NewContainer()
+	require.NoError(t, container.Bind(&app.AppProvider{BaseFolder: baseFolder}))
+	require.NoError(t, container.Bind(&env.EnvProvider{}))
+	require.NoError(t, container.Bind(&config.ConfigProvider{}))
+	require.NoError(t, container.Bind(&log.LogServiceProvider{Driver: "console"}))
+	require.NoError(t, container.Bind(&GormProvider{}))
+	return container
+}
+
+func TestGormService_GetDB(t *testing.T) {
+	container := newTestContainer(t)
+	ormService := framework.MustMake[contract.ORMService](container)
+
+	db, err := ormService.GetDB()
+	require.NoError(t, err)
+	ok, err := ormService.CanConnect(context.Background(), db)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, db.AutoMigrate(&testUser{}))
+	require.NoError(t, db.Create(&testUser{Name: "foo"}).Error)
+
+	var user testUser
+	require.NoError(t, db.WithContext(context.Background()).First(&user, "name = ?", "foo").Error)
+	assert.Equal(t, "foo", user.Name)
+
+	// 同一个连接复用同一个连接池
+	db2, err := ormService.GetDB(WithConnection("sqlite"))
+	require.NoError(t, err)
+	assert.Same(t, db, db2)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+
+	// 不同的连接使用不同的连接池
+	other, err := ormService.GetDB(WithConnection("other"))
+	require.NoError(t, err)
+	assert.NotSame(t, db, other)
+}
+
+func TestGormService_GetDB_UnknownConnection(t *testing.T) {
+	container := newTestContainer(t)
+	ormService := framework.MustMake[contract.ORMService](container)
+
+	_, err := ormService.GetDB(WithConnection("not_exist"))
+	assert.Error(t, err)
+}
+
+func TestGormService_GetDB_UnknownDriver(t *testing.T) {
+	container := newTestContainer(t)
+	ormService := framework.MustMake[contract.ORMService](container)
+
+	_, err := ormService.GetDB(WithConnection("unknown"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database driver not support: foo")
+}
+
+func TestGormService_Shutdown(t *testing.T) {
+	container := newTestContainer(t)
+	ormService := framework.MustMake[contract.ORMService](container)
+
+	db, err := ormService.GetDB()
+	require.NoError(t, err)
+	other, err := ormService.GetDB(WithConnection("other"))
+	require.NoError(t, err)
+
+	// 容器关闭的时候关闭所有的连接池
+	require.NoError(t, container.Shutdown(context.Background()))
+	for _, gdb := range []*gorm.DB{db, other} {
+		sqlDB, err := gdb.DB()
+		require.NoError(t, err)
+		assert.Error(t, sqlDB.Ping())
+	}
+
+	// 关闭之后重新获取服务会创建新的连接池
+	ormService = framework.MustMake[contract.ORMService](container)
+	db2, err := ormService.GetDB()
+	require.NoError(t, err)
+	assert.NotSame(t, db, db2)
+	ok, err := ormService.CanConnect(context.Background(), db2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GetBaseConfig 读取redis.yaml根目录的通用配置
+func GetBaseConfig(c framework.Container) *contract.RedisConfig {
+	logService := framework.MustMake[contract.Log](c)
+	config := &contract.RedisConfig{Options: &redis.Options{}}
+	opt := WithConfigPath("redis")
+	if err := opt(c, config); err != nil {
+		// 配置文件不存在或者出错，使用默认配置
+		logService.Error(context.Background(), "parse redis config error", map[string]interface{}{
+			"err": err,
+		})
+	}
+	return config
+}
+
+// WithConnection 表示使用redis.yaml中某个名字的连接，比如 WithConnection("cache") 读取 redis.cache
+// 会替换掉默认连接的配置, 需要放在WithRedisConfig等修改配置的option之前
+func WithConnection(name string) contract.RedisOption {
+	return WithConnectionPath("redis." + name)
+}
+
+// WithConnectionPath 使用某个配置路径作为一个完整的连接, 比如 WithConnectionPath("cache") 读取cache.yaml中的host等配置
+// 在redis.yaml通用配置的基础上读取, 不保留默认连接中的密码等配置
+func WithConnectionPath(configPath string) contract.RedisOption {
+	return func(container framework.Container, config *contract.RedisConfig) error {
+		*config.Options = *GetBaseConfig(container).Options
+		return WithConfigPath(configPath)(container, config)
+	}
+}
+
+// WithConfigPath 加载配置文件地址，会覆盖redis.yaml中的通用配置
+func WithConfigPath(configPath string) contract.RedisOption {
+	return func(container framework.Container, config *contract.RedisConfig) error {
+		configService := framework.MustMake[contract.Config](container)
+		if !configService.IsExist(configPath) {
+			return errors.New("redis config not exist: " + configPath)
+		}
+		conf := configService.GetStringMapString(configPath)
+
+		// 读取config配置
+		if host, ok := conf["host"]; ok && host != "" {
+			port := "6379"
+			if p, ok := conf["port"]; ok && p != "" {
+				port = p
+			}
+			config.Addr = net.JoinHostPort(host, port)
+		}
+		if db, ok := conf["db"]; ok && db != "" {
+			t, err := strconv.Atoi(db)
+			if err != nil {
+				return err
+			}
+			config.DB = t
+		}
+		if username, ok := conf["username"]; ok {
+			config.Username = username
+		}
+		if password, ok := conf["password"]; ok {
+			config.Password = password
+		}
+		if timeout, ok := conf["timeout"]; ok && timeout != "" {
+			t, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			config.DialTimeout = t
+		}
+		if timeout, ok := conf["read_timeout"]; ok && timeout != "" {
+			t, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			config.ReadTimeout = t
+		}
+		if timeout, ok := conf["write_timeout"]; ok && timeout != "" {
+			t, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			config.WriteTimeout = t
+		}
+		if cnt, ok := conf["conn_min_idle"]; ok && cnt != "" {
+			t, err := strconv.Atoi(cnt)
+			if err != nil {
+				return err
+			}
+			config.MinIdleConns = t
+		}
+		if max, ok := conf["conn_max_open"]; ok && max != "" {
+			t, err := strconv.Atoi(max)
+			if err != nil {
+				return err
+			}
+			config.PoolSize = t
+		}
+		if timeout, ok := conf["conn_max_lifetime"]; ok && timeout != "" {
+			t, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			config.MaxConnAge = t
+		}
+		if timeout, ok := conf["conn_max_idletime"]; ok && timeout != "" {
+			t, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			config.IdleTimeout = t
+		}
+		return nil
+	}
+}
+
+// WithRedisConfig 表示自行配置redis的配置信息
+func WithRedisConfig(f func(options *contract.RedisConfig)) contract.RedisOption {
+	return func(container framework.Container, config *contract.RedisConfig) error {
+		f(config)
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisService 代表redis服务
+type RedisService struct {
+	container framework.Container      // 服务容器
+	clients   map[string]*redis.Client // key为uniqKey, value为redis.Client (连接池）
+
+	lock *sync.RWMutex
+}
+
+// NewRedisService 代表实例化Client
+func NewRedisService(params ...interface{}) (interface{}, error) {
+	if len(params) != 1 {
+		return nil, errors.New("param error")
+	}
+	container := params[0].(framework.Container)
+	clients := make(map[string]*redis.Client)
+	lock := &sync.RWMutex{}
+	return &RedisService{
+		container: container,
+		clients:   clients,
+		lock:      lock,
+	}, nil
+}
+
+// GetClient 获取Client实例, 先使用redis.default指定的连接, 然后使用option修改, 比如 WithConnection 选择其他连接
+func (app *RedisService) GetClient(option ...contract.RedisOption) (*redis.Client, error) {
+	// 读取默认配置
+	config := GetBaseConfig(app.container)
+
+	// 先使用默认连接, 之后的option可以修改默认连接中的配置
+	configService := framework.MustMake[contract.Config](app.container)
+	if name := configService.GetString("redis.default"); name != "" {
+		if err := WithConnection(name)(app.container, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// option对opt进行修改
+	for _, opt := range option {
+		if err := opt(app.container, config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Addr == "" {
+		return nil, errors.New("redis connection not set, please set redis.default or use WithConnection")
+	}
+
+	// 根据配置生成唯一标识
+	key := config.UniqKey()
+
+	// 判断是否已经实例化了redis.Client
+	app.lock.RLock()
+	if db, ok := app.clients[key]; ok {
+		app.lock.RUnlock()
+		return db, nil
+	}
+	app.lock.RUnlock()
+
+	// 没有实例化redis.Client，那么就要进行实例化操作
+	app.lock.Lock()
+	defer app.lock.Unlock()
+
+	// 加锁之后再判断一次，防止并发时重复创建连接池
+	if db, ok := app.clients[key]; ok {
+		return db, nil
+	}
+
+	// 实例化
+	client := redis.NewClient(config.Options)
+
+	// 挂载到map中，结束配置
+	app.clients[key] = client
+
+	return client, nil
+}
+
+// Shutdown 关闭所有已经创建的client, 关闭之后清空缓存, 再次调用GetClient会重新创建client
+func (app *RedisService) Shutdown(ctx context.Context) error {
+	app.lock.Lock()
+	clients := app.clients
+	app.clients = make(map[string]*redis.Client)
+	app.lock.Unlock()
+
+	var firstErr error
+	for _, client := range clients {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
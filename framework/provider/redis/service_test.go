@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"context"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/app"
+	"goweb/framework/provider/config"
+	"goweb/framework/provider/env"
+	"goweb/framework/provider/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestContainer 使用临时目录下的redis.yaml初始化一个容器, redis.yaml中的端口通过env(...)读取
+func newTestContainer(t *testing.T, server *miniredis.Miniredis) framework.Container {
+	baseFolder := t.TempDir()
+	configFolder := filepath.Join(baseFolder, "config", "testing")
+	require.NoError(t, os.MkdirAll(configFolder, os.ModePerm))
+
+	redisConfig := `conn_max_open: 5
+default: write
+write:
+  host: 127.0.0.1
+  port: env(REDIS_TEST_PORT)
+  db: 0
+  password: write-secret
+  read_timeout: 2s
+read:
+  host: 127.0.0.1
+  port: env(REDIS_TEST_PORT)
+  db: 1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configFolder, "redis.yaml"), []byte(redisConfig), 0644))
+	t.Setenv("APP_ENV", contract.EnvTesting)
+	t.Setenv("REDIS_TEST_PORT", server.Port())
+
+	container := framework.NewContainer()
+	require.NoError(t, container.Bind(&app.AppProvider{BaseFolder: baseFolder}))
+	require.NoError(t, container.Bind(&env.EnvProvider{}))
+	require.NoError(t, container.Bind(&config.ConfigProvider{}))
+	require.NoError(t, container.Bind(&log.LogServiceProvider{Driver: "console"}))
+	require.NoError(t, container.Bind(&RedisProvider{}))
+	return container
+}
+
+func TestRedisService_GetClient(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("write-secret")
+	container := newTestContainer(t, server)
+	redisService := framework.MustMake[contract.RedisService](container)
+	ctx := context.Background()
+
+	client, err := redisService.GetClient()
+	require.NoError(t, err)
+	assert.Equal(t, server.Addr(), client.Options().Addr)
+	assert.Equal(t, 5, client.Options().PoolSize)
+	assert.Equal(t, 2*time.Second, client.Options().ReadTimeout)
+	require.NoError(t, client.Set(ctx, "foo", "bar", 0).Err())
+	assert.Equal(t, "bar", mustGet(t, server, 0, "foo"))
+
+	// 相同配置复用同一个连接
+	client2, err := redisService.GetClient(WithConnection("write"))
+	require.NoError(t, err)
+	assert.Same(t, client, client2)
+
+	// 不同的连接使用不同的client
+	read, err := redisService.GetClient(WithConnection("read"))
+	require.NoError(t, err)
+	assert.NotSame(t, client, read)
+	// 其他连接不使用默认连接的密码和超时时间
+	assert.Empty(t, read.Options().Password)
+	assert.NotEqual(t, 2*time.Second, read.Options().ReadTimeout)
+	assert.Equal(t, 1, read.Options().DB)
+
+	// 自定义配置
+	custom, err := redisService.GetClient(WithConnection("read"), WithRedisConfig(func(options *contract.RedisConfig) {
+		options.DB = 2
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, custom.Options().DB)
+
+	// 修改默认连接的配置, 不会被默认连接覆盖
+	withDB, err := redisService.GetClient(WithRedisConfig(func(options *contract.RedisConfig) {
+		options.DB = 3
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 3, withDB.Options().DB)
+	assert.Equal(t, "write-secret", withDB.Options().Password)
+	require.NoError(t, withDB.Set(ctx, "foo", "db3", 0).Err())
+	assert.Equal(t, "db3", mustGet(t, server, 3, "foo"))
+
+	// 只有密码或者超时时间不同的配置也使用不同的client
+	withPassword, err := redisService.GetClient(WithRedisConfig(func(options *contract.RedisConfig) {
+		options.Password = "secret"
+	}))
+	require.NoError(t, err)
+	assert.NotSame(t, client, withPassword)
+	assert.Equal(t, "secret", withPassword.Options().Password)
+	withTimeout, err := redisService.GetClient(WithRedisConfig(func(options *contract.RedisConfig) {
+		options.ReadTimeout = time.Second
+	}))
+	require.NoError(t, err)
+	assert.NotSame(t, client, withTimeout)
+	assert.Equal(t, time.Second, withTimeout.Options().ReadTimeout)
+
+	_, err = redisService.GetClient(WithConnection("not_exist"))
+	assert.Error(t, err)
+}
+
+func TestRedisService_Shutdown(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("write-secret")
+	container := newTestContainer(t, server)
+	redisService := framework.MustMake[contract.RedisService](container)
+	ctx := context.Background()
+
+	client, err := redisService.GetClient()
+	require.NoError(t, err)
+	read, err := redisService.GetClient(WithConnection("read"))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx).Err())
+
+	// 容器关闭的时候关闭所有的client
+	require.NoError(t, container.Shutdown(ctx))
+	assert.ErrorIs(t, client.Ping(ctx).Err(), redis.ErrClosed)
+	assert.ErrorIs(t, read.Ping(ctx).Err(), redis.ErrClosed)
+
+	// 关闭之后重新获取服务会创建新的client
+	redisService = framework.MustMake[contract.RedisService](container)
+	client2, err := redisService.GetClient()
+	require.NoError(t, err)
+	assert.NotSame(t, client, client2)
+	require.NoError(t, client2.Ping(ctx).Err())
+}
+
+func mustGet(t *testing.T, server *miniredis.Miniredis, db int, key string) string {
+	val, err := server.DB(db).Get(key)
+	require.NoError(t, err)
+	return val
+}
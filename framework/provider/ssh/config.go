@@ -0,0 +1,81 @@
+package ssh
+
+import (
+	"errors"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// WithConfigPath 读取配置文件中的ssh连接信息, 比如 WithConfigPath("deploy") 读取deploy.yaml
+// 支持的配置项: host, port, user, password, rsa_key, timeout(毫秒), known_hosts, insecure_ignore_host_key
+// 默认使用~/.ssh/known_hosts校验服务端的公钥, 只有insecure_ignore_host_key为true的时候才不校验
+// 认证方式和公钥校验方式会记录到AuthKey中, 密码或者私钥不同的配置不会复用同一个连接
+func WithConfigPath(configPath string) contract.SSHOption {
+	return func(container framework.Container, config *contract.SSHConfig) error {
+		configService := framework.MustMake[contract.Config](container)
+		if !configService.IsExist(configPath) {
+			return errors.New("ssh config not exist: " + configPath)
+		}
+		conf := configService.GetStringMap(configPath)
+		authKey := []string{configPath}
+
+		if host, ok := conf["host"]; ok {
+			config.Host = cast.ToString(host)
+		}
+		if port, ok := conf["port"]; ok {
+			config.Port = cast.ToString(port)
+		}
+		if user, ok := conf["user"]; ok {
+			config.User = cast.ToString(user)
+		}
+		if password, ok := conf["password"]; ok && cast.ToString(password) != "" {
+			config.Auth = append(config.Auth, ssh.Password(cast.ToString(password)))
+			authKey = append(authKey, "password:"+cast.ToString(password))
+		}
+		if rsaKey, ok := conf["rsa_key"]; ok && cast.ToString(rsaKey) != "" {
+			key, err := os.ReadFile(cast.ToString(rsaKey))
+			if err != nil {
+				return err
+			}
+			signer, err := ssh.ParsePrivateKey(key)
+			if err != nil {
+				return err
+			}
+			config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+			authKey = append(authKey, "rsa_key:"+string(key))
+		}
+		if timeout, ok := conf["timeout"]; ok && cast.ToInt(timeout) > 0 {
+			config.Timeout = time.Duration(cast.ToInt(timeout)) * time.Millisecond
+		}
+		if knownHosts, ok := conf["known_hosts"]; ok && cast.ToString(knownHosts) != "" {
+			callback, err := knownhosts.New(cast.ToString(knownHosts))
+			if err != nil {
+				return err
+			}
+			config.HostKeyCallback = callback
+			authKey = append(authKey, "known_hosts:"+cast.ToString(knownHosts))
+		}
+		if insecure, ok := conf["insecure_ignore_host_key"]; ok && cast.ToBool(insecure) {
+			config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+			authKey = append(authKey, "insecure_ignore_host_key")
+		}
+		config.AuthKey = strings.Join(authKey, "\n")
+		return nil
+	}
+}
+
+// WithSSHConfig 表示自行配置ssh的配置信息
+// 修改Auth或者HostKeyCallback的时候需要同时设置AuthKey, 否则GetClient每次都会创建新的连接
+func WithSSHConfig(f func(options *contract.SSHConfig)) contract.SSHOption {
+	return func(container framework.Container, config *contract.SSHConfig) error {
+		f(config)
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHService 代表ssh服务
+type SSHService struct {
+	container framework.Container    // 服务容器
+	clients   map[string]*ssh.Client // key为uniqKey, value为ssh.Client
+
+	lock *sync.RWMutex
+}
+
+// NewSSHService 代表实例化ssh服务
+func NewSSHService(params ...interface{}) (interface{}, error) {
+	if len(params) != 1 {
+		return nil, errors.New("param error")
+	}
+	container := params[0].(framework.Container)
+	clients := make(map[string]*ssh.Client)
+	lock := &sync.RWMutex{}
+	return &SSHService{container: container, clients: clients, lock: lock}, nil
+}
+
+// GetClient 获取Client实例, AuthKey相同的配置复用同一个连接, 连接断开之后会重新创建
+// 设置了Auth或者HostKeyCallback却没有设置AuthKey的时候无法判断认证方式是否相同, 每次都创建新的连接, 由调用方负责关闭
+func (s *SSHService) GetClient(option ...contract.SSHOption) (*ssh.Client, error) {
+	// 默认配置
+	config := &contract.SSHConfig{
+		NetWork:      "tcp",
+		Port:         "22",
+		ClientConfig: &ssh.ClientConfig{},
+	}
+
+	// option对opt进行修改
+	for _, opt := range option {
+		if err := opt(s.container, config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Host == "" {
+		return nil, errors.New("ssh host not set")
+	}
+	addr := net.JoinHostPort(config.Host, config.Port)
+	if config.AuthKey == "" && (len(config.Auth) > 0 || config.HostKeyCallback != nil) {
+		return ssh.Dial(config.NetWork, addr, config.ClientConfig)
+	}
+
+	// 没有设置校验方式的时候使用~/.ssh/known_hosts校验服务端的公钥
+	if config.HostKeyCallback == nil {
+		callback, err := defaultHostKeyCallback()
+		if err != nil {
+			return nil, err
+		}
+		config.HostKeyCallback = callback
+	}
+
+	// 查看是否已经实例化过
+	key := config.UniqKey()
+	s.lock.RLock()
+	if client, ok := s.clients[key]; ok {
+		s.lock.RUnlock()
+		return client, nil
+	}
+	s.lock.RUnlock()
+
+	// 没有实例化，那么就要进行实例化操作
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if client, ok := s.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := ssh.Dial(config.NetWork, addr, config.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// 挂载到map中，结束配置
+	s.clients[key] = client
+	// 连接断开之后从map中移除, 下次获取的时候重新连接
+	go func() {
+		_ = client.Wait()
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.clients[key] == client {
+			delete(s.clients, key)
+		}
+	}()
+	return client, nil
+}
+
+// defaultHostKeyCallback 使用当前用户的~/.ssh/known_hosts校验服务端的公钥
+func defaultHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts for ssh host key check: %w, set known_hosts or insecure_ignore_host_key in config", err)
+	}
+	return callback, nil
+}
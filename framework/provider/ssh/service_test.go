@@ -0,0 +1,157 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"goweb/framework"
+	"goweb/framework/contract"
+	"goweb/framework/provider/app"
+	"goweb/framework/provider/config"
+	"goweb/framework/provider/env"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer 只支持密码认证的ssh服务端, 记录所有的连接用来模拟连接断开
+type testSSHServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+
+	lock  sync.Mutex
+	conns []*ssh.ServerConn
+}
+
+func newTestSSHServer(t *testing.T, password string) *testSSHServer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &testSSHServer{listener: listener, config: serverConfig}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		server.closeConns()
+	})
+	return server
+}
+
+func (server *testSSHServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			sshConn, chans, reqs, err := ssh.NewServerConn(conn, server.config)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			server.lock.Lock()
+			server.conns = append(server.conns, sshConn)
+			server.lock.Unlock()
+			go ssh.DiscardRequests(reqs)
+			for ch := range chans {
+				ch.Reject(ssh.Prohibited, "not supported")
+			}
+		}()
+	}
+}
+
+func (server *testSSHServer) closeConns() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	for _, conn := range server.conns {
+		conn.Close()
+	}
+	server.conns = nil
+}
+
+// newTestContainer 使用临时目录下的配置初始化一个容器, right和wrong两个配置只有密码不同
+func newTestContainer(t *testing.T, server *testSSHServer) framework.Container {
+	baseFolder := t.TempDir()
+	configFolder := filepath.Join(baseFolder, "config", "testing")
+	require.NoError(t, os.MkdirAll(configFolder, os.ModePerm))
+
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	require.NoError(t, err)
+	for name, password := range map[string]string{"right": "secret", "wrong": "other"} {
+		conf := "host: " + host + "\n" +
+			"port: " + port + "\n" +
+			"user: deploy\n" +
+			"password: " + password + "\n" +
+			"insecure_ignore_host_key: true\n"
+		require.NoError(t, os.WriteFile(filepath.Join(configFolder, name+".yaml"), []byte(conf), 0644))
+	}
+	t.Setenv("APP_ENV", contract.EnvTesting)
+
+	container := framework.NewContainer()
+	require.NoError(t, container.Bind(&app.AppProvider{BaseFolder: baseFolder}))
+	require.NoError(t, container.Bind(&env.EnvProvider{}))
+	require.NoError(t, container.Bind(&config.ConfigProvider{}))
+	require.NoError(t, container.Bind(&SSHProvider{}))
+	return container
+}
+
+func TestSSHService_GetClient(t *testing.T) {
+	server := newTestSSHServer(t, "secret")
+	container := newTestContainer(t, server)
+	sshService := framework.MustMake[contract.SSHService](container)
+
+	client, err := sshService.GetClient(WithConfigPath("right"))
+	require.NoError(t, err)
+
+	// 相同配置复用同一个连接
+	client2, err := sshService.GetClient(WithConfigPath("right"))
+	require.NoError(t, err)
+	assert.Same(t, client, client2)
+
+	// 只有密码不同的配置不能复用已经认证过的连接
+	_, err = sshService.GetClient(WithConfigPath("wrong"))
+	assert.Error(t, err)
+
+	// 自行设置了认证方式却没有设置AuthKey的时候每次都创建新的连接
+	custom, err := sshService.GetClient(WithConfigPath("right"), WithSSHConfig(func(options *contract.SSHConfig) {
+		options.AuthKey = ""
+	}))
+	require.NoError(t, err)
+	defer custom.Close()
+	assert.NotSame(t, client, custom)
+}
+
+func TestSSHService_GetClient_Reconnect(t *testing.T) {
+	server := newTestSSHServer(t, "secret")
+	container := newTestContainer(t, server)
+	sshService := framework.MustMake[contract.SSHService](container)
+
+	client, err := sshService.GetClient(WithConfigPath("right"))
+	require.NoError(t, err)
+
+	// 连接断开之后重新连接
+	server.closeConns()
+	require.Error(t, client.Wait())
+	assert.Eventually(t, func() bool {
+		client2, err := sshService.GetClient(WithConfigPath("right"))
+		return err == nil && client2 != client
+	}, time.Second, 10*time.Millisecond)
+}
@@ -22,7 +22,7 @@ type TraceService struct {
 
 func NewTraceService(params ...interface{}) (interface{}, error) {
 	c := params[0].(framework.Container)
-	idService := c.MustMake(contract.IDKey).(contract.IDService)
+	idService := framework.MustMake[contract.IDService](c)
 	return &TraceService{idService: idService}, nil
 }
 
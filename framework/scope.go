@@ -0,0 +1,173 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Scope 服务的作用域, 比如一次http请求
+// 作用域服务在作用域内只实例化一次, 其他服务直接从容器中获取单例
+type Scope struct {
+	container *ServiceContainer
+	// instances 作用域内已经实例化的服务
+	instances map[string]interface{}
+	// closers 作用域内实现了io.Closer的实例, 按照实例化的顺序保存
+	closers []io.Closer
+	closed  bool
+
+	lock sync.Mutex
+}
+
+// NewScope 创建一个作用域, container为NewContainer创建的容器, 或者服务提供者中拿到的容器
+func NewScope(container Container) (*Scope, error) {
+	switch c := container.(type) {
+	case *ServiceContainer:
+		return &Scope{container: c, instances: map[string]interface{}{}}, nil
+	case *resolver:
+		return &Scope{container: c.ServiceContainer, instances: map[string]interface{}{}}, nil
+	case *Scope:
+		return &Scope{container: c.container, instances: map[string]interface{}{}}, nil
+	case *scopeResolver:
+		return &Scope{container: c.Scope.container, instances: map[string]interface{}{}}, nil
+	}
+	return nil, errors.New("container not support scope")
+}
+
+// Bind 绑定服务提供者到容器中
+func (s *Scope) Bind(provider ServiceProvider) error {
+	return s.container.Bind(provider)
+}
+
+// IsBind 关键字凭证是否已经绑定服务提供者
+func (s *Scope) IsBind(key string) bool {
+	return s.container.IsBind(key)
+}
+
+// Make 获取一个服务, 作用域服务在作用域内只实例化一次
+func (s *Scope) Make(key string) (interface{}, error) {
+	return s.make(key, nil, false, nil)
+}
+
+// MustMake 获取一个服务, 获取失败的时候panic
+func (s *Scope) MustMake(key string) interface{} {
+	ins, err := s.make(key, nil, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+// MakeNew 根据参数实例化一个新的服务
+func (s *Scope) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return s.make(key, params, true, nil)
+}
+
+// Shutdown 关闭作用域所在的容器, 作用域本身使用Close结束
+func (s *Scope) Shutdown(ctx context.Context) error {
+	return s.container.Shutdown(ctx)
+}
+
+// Close 结束作用域, 按照实例化的相反顺序关闭实现了io.Closer的实例, 返回第一个错误
+func (s *Scope) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	closers := s.closers
+	s.closers = nil
+	s.instances = map[string]interface{}{}
+	s.lock.Unlock()
+
+	var err error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if e := closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// make 获取一个服务, 不是作用域服务的直接交给容器处理
+func (s *Scope) make(key string, params []interface{}, forceNew bool, chain []string) (interface{}, error) {
+	sp := s.container.findServiceProvider(key)
+	if sp == nil || !isScoped(sp) {
+		return s.container.make(key, params, forceNew, chain)
+	}
+	s.container.addDependency(chain, key)
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil, errors.New("scope closed, can not make " + key)
+	}
+	if ins, ok := s.instances[key]; ok && !forceNew {
+		s.lock.Unlock()
+		return ins, nil
+	}
+	s.lock.Unlock()
+
+	next, err := nextChain(chain, key)
+	if err != nil {
+		return nil, err
+	}
+	r := &scopeResolver{Scope: s, chain: next}
+	ins, err := newInstanceWith(sp, params, r, r.finish)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !forceNew {
+		// 并发实例化的时候，以先存入作用域的实例为准
+		if exist, ok := s.instances[key]; ok {
+			return exist, nil
+		}
+		s.instances[key] = ins
+	}
+	if closer, ok := ins.(io.Closer); ok {
+		s.closers = append(s.closers, closer)
+	}
+	return ins, nil
+}
+
+// scopeResolver 在作用域内实例化服务的时候传递给服务提供者的容器, 记录了当前实例化的调用链
+type scopeResolver struct {
+	*Scope
+	chain []string
+	done  int32
+}
+
+// finish 标记实例化结束
+func (r *scopeResolver) finish() {
+	atomic.StoreInt32(&r.done, 1)
+}
+
+// currentChain 获取当前的调用链, 实例化结束之后返回nil
+func (r *scopeResolver) currentChain() []string {
+	if atomic.LoadInt32(&r.done) == 1 {
+		return nil
+	}
+	return r.chain
+}
+
+func (r *scopeResolver) Make(key string) (interface{}, error) {
+	return r.Scope.make(key, nil, false, r.currentChain())
+}
+
+func (r *scopeResolver) MustMake(key string) interface{} {
+	ins, err := r.Scope.make(key, nil, false, r.currentChain())
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+func (r *scopeResolver) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return r.Scope.make(key, params, true, r.currentChain())
+}
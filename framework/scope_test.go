@@ -0,0 +1,110 @@
+package framework
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScopedProvider 测试使用的作用域服务提供者, 实例化的时候依次获取deps中的服务
+type testScopedProvider struct {
+	testProvider
+	closed *[]string
+}
+
+func (p *testScopedProvider) IsScoped() bool { return true }
+
+func (p *testScopedProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		p.lock.Lock()
+		p.count++
+		p.lock.Unlock()
+		return &testCloser{name: p.name, closed: p.closed}, nil
+	}
+}
+
+type testCloser struct {
+	name   string
+	closed *[]string
+}
+
+func (c *testCloser) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	if c.name == "fail" {
+		return errors.New("close " + c.name + " error")
+	}
+	return nil
+}
+
+func TestScope_Make(t *testing.T) {
+	closed := []string{}
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testProvider{name: "single", isDefer: true}))
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "a", deps: []string{"single"}}, closed: &closed}))
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "b", deps: []string{"a"}}, closed: &closed}))
+
+	// 作用域服务不能直接从容器中获取
+	_, err := container.Make("a")
+	assert.Error(t, err)
+
+	scope1, err := NewScope(container)
+	require.NoError(t, err)
+	scope2, err := NewScope(container)
+	require.NoError(t, err)
+
+	b1 := scope1.MustMake("b")
+	a1 := scope1.MustMake("a")
+	assert.Same(t, b1, scope1.MustMake("b"))
+	assert.NotSame(t, a1, scope2.MustMake("a"))
+	// 普通服务在作用域之间共享
+	assert.Same(t, scope1.MustMake("single"), scope2.MustMake("single"))
+	assert.Same(t, container.MustMake("single"), scope1.MustMake("single"))
+
+	infos := container.ProviderInfos()
+	assert.Equal(t, []string{"single"}, infos[0].Dependencies)
+	assert.Equal(t, []string{"a"}, infos[1].Dependencies)
+
+	// 按照实例化的相反顺序关闭
+	require.NoError(t, scope1.Close())
+	assert.Equal(t, []string{"b", "a"}, closed)
+	require.NoError(t, scope1.Close())
+	assert.Equal(t, []string{"b", "a"}, closed)
+
+	_, err = scope1.Make("a")
+	assert.Error(t, err)
+	require.NoError(t, scope2.Close())
+	assert.Equal(t, []string{"b", "a", "a"}, closed)
+}
+
+func TestScope_Close(t *testing.T) {
+	closed := []string{}
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "fail"}, closed: &closed}))
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "a"}, closed: &closed}))
+
+	scope, err := NewScope(container)
+	require.NoError(t, err)
+	scope.MustMake("a")
+	scope.MustMake("fail")
+	ins, err := scope.MakeNew("a", nil)
+	require.NoError(t, err)
+	assert.NotSame(t, ins, scope.MustMake("a"))
+
+	// 关闭出错的时候继续关闭剩余的实例, 返回第一个错误
+	assert.EqualError(t, scope.Close(), "close fail error")
+	assert.Equal(t, []string{"a", "fail", "a"}, closed)
+}
+
+func TestScope_Cycle(t *testing.T) {
+	closed := []string{}
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "a", deps: []string{"b"}}, closed: &closed}))
+	require.NoError(t, container.Bind(&testScopedProvider{testProvider: testProvider{name: "b", deps: []string{"a"}}, closed: &closed}))
+
+	scope, err := NewScope(container)
+	require.NoError(t, err)
+	_, err = scope.Make("a")
+	assert.EqualError(t, err, "dependency cycle detected: a -> b -> a")
+}
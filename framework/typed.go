@@ -0,0 +1,134 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Resolver 能够根据关键字凭证获取服务, Container, gin.Context, cobra.Command 都实现了这个接口
+type Resolver interface {
+	Make(key string) (interface{}, error)
+	MustMake(key string) interface{}
+	MakeNew(key string, params []interface{}) (interface{}, error)
+}
+
+// typeKeys 记录类型对应的关键字凭证, key为reflect.Type, value为字符串凭证
+var typeKeys sync.Map
+
+// typeOf 获取类型T的reflect.Type, T为接口的时候返回接口本身的类型
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// RegisterKey 注册类型T对应的字符串凭证, 之后就可以通过 Make[T] 获取这个凭证对应的服务
+// 一般在contract中为服务接口注册, 比如 RegisterKey[Config](ConfigKey)
+func RegisterKey[T any](key string) {
+	typeKeys.Store(typeOf[T](), key)
+}
+
+// KeyOf 获取类型T对应的字符串凭证, 没有通过RegisterKey注册的类型, 使用 type:包路径.类型名 作为凭证
+func KeyOf[T any]() string {
+	t := typeOf[T]()
+	if key, ok := typeKeys.Load(t); ok {
+		return key.(string)
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return "type:" + t.PkgPath() + "." + t.Name()
+	}
+	return "type:" + t.String()
+}
+
+// BindAs 以类型T绑定服务提供者, 绑定之后可以通过 Make[T] 获取服务
+// 服务提供者原来的字符串凭证不会被绑定, 需要的话单独调用Bind
+func BindAs[T any](c Container, provider ServiceProvider) error {
+	key := KeyOf[T]()
+	if scoped, ok := provider.(ScopedProvider); ok {
+		return c.Bind(&typedScopedProvider{typedProvider{provider, key}, scoped})
+	}
+	return c.Bind(&typedProvider{provider, key})
+}
+
+// Make 获取类型T对应的服务, 服务没有绑定或者类型不匹配的时候返回错误
+func Make[T any](c Resolver) (T, error) {
+	return MakeKey[T](c, KeyOf[T]())
+}
+
+// MakeKey 根据字符串凭证获取服务, 并检查服务的类型是否为T
+func MakeKey[T any](c Resolver, key string) (T, error) {
+	ins, err := c.Make(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return assertType[T](key, ins)
+}
+
+// MustMake 获取类型T对应的服务, 服务没有绑定或者类型不匹配的时候panic
+func MustMake[T any](c Resolver) T {
+	ins, err := Make[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+// MustMakeKey 根据字符串凭证获取服务, 服务没有绑定或者类型不匹配的时候panic
+func MustMakeKey[T any](c Resolver, key string) T {
+	ins, err := MakeKey[T](c, key)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+// MakeNew 根据参数实例化一个类型T对应的新服务
+func MakeNew[T any](c Resolver, params ...interface{}) (T, error) {
+	key := KeyOf[T]()
+	ins, err := c.MakeNew(key, params)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return assertType[T](key, ins)
+}
+
+// assertType 检查服务实例是否为类型T
+func assertType[T any](key string, ins interface{}) (T, error) {
+	ret, ok := ins.(T)
+	if !ok {
+		return ret, &TypeError{Key: key, Want: typeOf[T](), Got: reflect.TypeOf(ins)}
+	}
+	return ret, nil
+}
+
+// TypeError 获取的服务实例和期望的类型不匹配的时候返回的错误
+type TypeError struct {
+	Key  string
+	Want reflect.Type
+	Got  reflect.Type
+}
+
+func (e *TypeError) Error() string {
+	verb := "is not"
+	if e.Want.Kind() == reflect.Interface {
+		verb = "does not implement"
+	}
+	return fmt.Sprintf("contract %s: service type %v %s %v", e.Key, e.Got, verb, e.Want)
+}
+
+// typedProvider 将服务提供者以类型凭证绑定
+type typedProvider struct {
+	ServiceProvider
+	key string
+}
+
+func (p *typedProvider) Name() string { return p.key }
+
+// typedScopedProvider 保留作用域服务提供者的IsScoped
+type typedScopedProvider struct {
+	typedProvider
+	scoped ScopedProvider
+}
+
+func (p *typedScopedProvider) IsScoped() bool { return p.scoped.IsScoped() }
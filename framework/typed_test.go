@@ -0,0 +1,92 @@
+package framework
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNamer interface {
+	TestName() string
+}
+
+type testNamed struct{ name string }
+
+func (n *testNamed) TestName() string { return n.name }
+
+type testNamedProvider struct {
+	testProvider
+}
+
+func (p *testNamedProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		p.lock.Lock()
+		p.count++
+		p.lock.Unlock()
+		if len(params) > 0 {
+			return &testNamed{name: params[0].(string)}, nil
+		}
+		return &testNamed{name: p.name}, nil
+	}
+}
+
+func (p *testNamedProvider) Params(c Container) []interface{} { return nil }
+
+func TestMake(t *testing.T) {
+	container := NewContainer()
+	require.NoError(t, container.Bind(&testNamedProvider{testProvider{name: "named", isDefer: true}}))
+
+	named, err := MakeKey[testNamer](container, "named")
+	require.NoError(t, err)
+	assert.Equal(t, "named", named.TestName())
+	assert.Same(t, named, MustMakeKey[*testNamed](container, "named"))
+
+	// 类型不匹配的时候返回描述性的错误
+	_, err = MakeKey[error](container, "named")
+	var typeErr *TypeError
+	require.True(t, errors.As(err, &typeErr))
+	assert.EqualError(t, err, "contract named: service type *framework.testNamed does not implement error")
+	assert.Panics(t, func() { MustMakeKey[string](container, "named") })
+
+	// 没有绑定的类型
+	_, err = Make[testNamer](container)
+	assert.EqualError(t, err, "contract type:goweb/framework.testNamer have not register")
+}
+
+func TestBindAs(t *testing.T) {
+	container := NewContainer()
+	require.NoError(t, BindAs[testNamer](container, &testNamedProvider{testProvider{name: "named", isDefer: true}}))
+	assert.True(t, container.IsBind(KeyOf[testNamer]()))
+	assert.False(t, container.IsBind("named"))
+
+	named := MustMake[testNamer](container)
+	assert.Equal(t, "named", named.TestName())
+	assert.Same(t, named, MustMake[testNamer](container))
+
+	other, err := MakeNew[testNamer](container, "other")
+	require.NoError(t, err)
+	assert.Equal(t, "other", other.TestName())
+
+	// 注册了凭证的类型使用注册的凭证
+	RegisterKey[*testNamed]("test:named")
+	assert.Equal(t, "test:named", KeyOf[*testNamed]())
+	require.NoError(t, container.Bind(&testNamedProvider{testProvider{name: "test:named", isDefer: true}}))
+	assert.Equal(t, "test:named", MustMake[*testNamed](container).TestName())
+}
+
+func TestBindAs_Scoped(t *testing.T) {
+	closed := []string{}
+	container := NewContainer()
+	require.NoError(t, BindAs[*testCloser](container, &testScopedProvider{testProvider: testProvider{name: "a"}, closed: &closed}))
+
+	_, err := Make[*testCloser](container)
+	assert.Error(t, err)
+
+	scope, err := NewScope(container)
+	require.NoError(t, err)
+	assert.Equal(t, "a", MustMake[*testCloser](scope).name)
+	require.NoError(t, scope.Close())
+	assert.Equal(t, []string{"a"}, closed)
+}
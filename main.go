@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"goweb/app/console"
 	"goweb/app/http"
 	"goweb/framework"
 	"goweb/framework/provider/app"
+	"goweb/framework/provider/cache"
 	"goweb/framework/provider/config"
 	"goweb/framework/provider/distributed"
 	"goweb/framework/provider/env"
 	"goweb/framework/provider/id"
 	"goweb/framework/provider/kernel"
 	"goweb/framework/provider/log"
+	"goweb/framework/provider/orm"
+	"goweb/framework/provider/redis"
+	"goweb/framework/provider/ssh"
 	"goweb/framework/provider/trace"
+	"os"
+	"time"
 )
 
 func main() {
@@ -19,24 +26,38 @@ func main() {
 	container := framework.NewContainer()
 	// 绑定App服务提供者
 	container.Bind(&app.AppProvider{})
-	container.Bind(&env.EnvProvider{})
+	// .env文件格式错误的时候直接退出
+	if err := container.Bind(&env.EnvProvider{}); err != nil {
+		os.Exit(1)
+	}
 	container.Bind(&distributed.LocalDistributedProvider{})
 	// // 后续初始化需要绑定的服务提供者...
-	container.Bind(&config.ConfigProvider{})
+	// 配置文件加载失败, 比如缺少必须的环境变量, 直接退出
+	if err := container.Bind(&config.ConfigProvider{}); err != nil {
+		os.Exit(1)
+	}
 	container.Bind(&id.IDProvider{})
 	container.Bind(&trace.TraceProvider{})
 	container.Bind(&log.LogServiceProvider{})
-	// container.Bind(&orm.GormProvider{})
-	// container.Bind(&redis.RedisProvider{})
-	// container.Bind(&cache.CacheProvider{})
-	// container.Bind(&ssh.SSHProvider{})
+	container.Bind(&orm.GormProvider{})
+	container.Bind(&redis.RedisProvider{})
+	container.Bind(&cache.CacheProvider{})
+	container.Bind(&ssh.SSHProvider{})
 
 
 	// 将HTTP引擎初始化,并且作为服务提供者绑定到服务容器中
-	if engine, err := http.NewHttpEngine(); err == nil {
+	if engine, err := http.NewHttpEngine(container); err == nil {
 		container.Bind(&kernel.KernelProvider{HttpEngine: engine})
 	}
 
-	// 运行root命令
-	console.RunCommand(container)
+	// 运行root命令, 命令执行失败的时候返回非0的退出码, 方便CI判断
+	err := console.RunCommand(container)
+	// 命令结束之后关闭容器中的服务, 比如写入异步日志缓冲区中剩余的日志
+	// app start和cron start收到退出信号的时候已经关闭过, 再次关闭不会重复调用
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	container.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		os.Exit(1)
+	}
 }
\ No newline at end of file